@@ -0,0 +1,514 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/path"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	ACTION_TYPE_GIT_INIT = "git-init"
+	ACTION_TYPE_CHMOD    = "chmod"
+	ACTION_TYPE_RENAME   = "rename"
+	ACTION_TYPE_DELETE   = "delete"
+	ACTION_TYPE_APPEND   = "append-to-file"
+	ACTION_TYPE_NOTE     = "print-note"
+)
+
+// GIT_ACTION_TIMEOUT is maximum duration of git commands executed by git-init
+// action
+const GIT_ACTION_TIMEOUT = time.Minute
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// authorRegex is regex for author in "Name <email>" format
+var authorRegex = regexp.MustCompile(`^(.+?)\s*<([^<>\s]+@[^<>\s]+)>$`)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Describe returns short description of action with variables values. If vars is
+// nil, variables are not substituted.
+func (a *ManifestAction) Describe(vars Variables) string {
+	render := func(s string) string {
+		if vars == nil {
+			return s
+		}
+
+		return applyVariables(s, vars)
+	}
+
+	files := render(strings.Join(a.Files, ", "))
+
+	switch a.Action {
+	case ACTION_TYPE_GIT_INIT:
+		switch {
+		case a.Message == "":
+			return "without commit"
+		case a.Author == "":
+			return fmt.Sprintf("commit %q", render(a.Message))
+		}
+
+		return fmt.Sprintf("commit %q by %s", render(a.Message), render(a.Author))
+
+	case ACTION_TYPE_CHMOD:
+		return files + " → " + render(a.Mode)
+	case ACTION_TYPE_RENAME:
+		return files + " → " + render(a.To)
+	case ACTION_TYPE_DELETE:
+		return files
+	case ACTION_TYPE_APPEND:
+		return render(a.File)
+	}
+
+	return ""
+}
+
+// Variables returns names of all variables used in action
+func (a *ManifestAction) Variables() []string {
+	var result []string
+
+	for _, value := range a.values() {
+		vars, _ := scanStringForVariables(value)
+		result = append(result, vars...)
+	}
+
+	return result
+}
+
+// values returns all values of action which can contain variables
+func (a *ManifestAction) values() []string {
+	return slices.Concat(a.Files, []string{a.Mode, a.To, a.File, a.Text, a.Message, a.Author})
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// runActions runs built-in actions from manifest in target directory. Files is
+// list of generated files used for matching globs.
+func runActions(tmpl *Template, dir string, files []string) error {
+	var err error
+
+	for i, action := range tmpl.Manifest.Actions {
+		if action.Action == ACTION_TYPE_NOTE {
+			fmtc.Printf("{c}%s{!}\n\n", strings.TrimRight(applyVariables(action.Text, tmpl.Vars), "\n"))
+			continue
+		}
+
+		fmtc.Printfn("{*}→ %s{!} {s-}(%s){!}", action.Action, action.Describe(tmpl.Vars))
+
+		files, err = runAction(action, tmpl.Vars, dir, files)
+
+		if err != nil {
+			return fmt.Errorf("Action #%d (%s) failed: %w", i+1, action.Action, err)
+		}
+	}
+
+	return nil
+}
+
+// runAction runs single action and returns updated list of generated files
+func runAction(action *ManifestAction, vars Variables, dir string, files []string) ([]string, error) {
+	var patterns []string
+
+	for _, pattern := range action.Files {
+		patterns = append(patterns, applyVariables(pattern, vars))
+	}
+
+	matched := matchFiles(patterns, files)
+
+	switch action.Action {
+	case ACTION_TYPE_GIT_INIT:
+		return files, runGitInitAction(
+			dir, applyVariables(action.Message, vars), applyVariables(action.Author, vars),
+		)
+
+	case ACTION_TYPE_CHMOD:
+		return files, runChmodAction(dir, applyVariables(action.Mode, vars), matched)
+
+	case ACTION_TYPE_RENAME:
+		return runRenameAction(dir, applyVariables(action.To, vars), matched, files)
+
+	case ACTION_TYPE_DELETE:
+		for _, file := range matched {
+			err := os.RemoveAll(dir + "/" + file)
+
+			if err != nil {
+				return files, err
+			}
+		}
+
+		return slices.DeleteFunc(files, func(f string) bool {
+			return slices.Contains(matched, f)
+		}), nil
+
+	case ACTION_TYPE_APPEND:
+		file := applyVariables(action.File, vars)
+		err := runAppendAction(dir, file, applyVariables(action.Text, vars))
+
+		if err != nil || slices.Contains(files, file) {
+			return files, err
+		}
+
+		return append(files, file), nil
+	}
+
+	return files, nil
+}
+
+// runGitInitAction initializes git repository and creates initial commit
+func runGitInitAction(dir, message, author string) error {
+	var env []string
+
+	if author != "" {
+		if !authorRegex.MatchString(author) {
+			return fmt.Errorf("Author %q must be in \"Name <email>\" format", author)
+		}
+
+		m := authorRegex.FindStringSubmatch(author)
+
+		env = append(env,
+			"GIT_AUTHOR_NAME="+m[1], "GIT_AUTHOR_EMAIL="+m[2],
+			"GIT_COMMITTER_NAME="+m[1], "GIT_COMMITTER_EMAIL="+m[2],
+		)
+	}
+
+	if !fsutil.IsExist(dir + "/.git") {
+		err := runGitCommand(dir, env, "init", "--quiet")
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if message == "" {
+		return nil
+	}
+
+	err := runGitCommand(dir, env, "add", "--all")
+
+	if err != nil {
+		return err
+	}
+
+	return runGitCommand(dir, env, "commit", "--quiet", "--message", message)
+}
+
+// runGitCommand runs git command in given directory
+func runGitCommand(dir string, env []string, args ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), GIT_ACTION_TIMEOUT)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+
+	output, err := cmd.CombinedOutput()
+
+	if err != nil {
+		return fmt.Errorf("git %s: %w (%s)", args[0], err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+// runChmodAction changes permissions of matched files
+func runChmodAction(dir, mode string, matched []string) error {
+	perms, err := parseFileMode(mode)
+
+	if err != nil {
+		return err
+	}
+
+	for _, file := range matched {
+		info, err := os.Lstat(dir + "/" + file)
+
+		if err != nil {
+			return err
+		}
+
+		// Permissions of symlinks can't be changed
+		if info.Mode()&os.ModeSymlink != 0 {
+			continue
+		}
+
+		err = os.Chmod(dir+"/"+file, perms)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runRenameAction renames matched files. If new path ends with slash, files are
+// moved to this directory.
+func runRenameAction(dir, to string, matched, files []string) ([]string, error) {
+	targets, err := getRenameTargets(to, matched)
+
+	if err != nil {
+		return files, err
+	}
+
+	for i, file := range matched {
+		target := targets[i]
+
+		if fsutil.IsExist(dir + "/" + target) {
+			return files, fmt.Errorf("Can't rename %s: %s already exists", file, target)
+		}
+
+		err := os.MkdirAll(path.Dir(dir+"/"+target), 0755)
+
+		if err == nil {
+			err = os.Rename(dir+"/"+file, dir+"/"+target)
+		}
+
+		if err != nil {
+			return files, err
+		}
+
+		files[slices.Index(files, file)] = target
+	}
+
+	return files, nil
+}
+
+// getRenameTargets returns new paths for matched files
+func getRenameTargets(to string, matched []string) ([]string, error) {
+	isDir := strings.HasSuffix(to, "/")
+	to = strings.TrimSuffix(to, "/")
+
+	switch {
+	case len(matched) == 0:
+		return nil, nil
+	case validateTargetPath(to) != nil:
+		return nil, fmt.Errorf("Invalid new path %q", to)
+	case !isDir && len(matched) > 1:
+		return nil, fmt.Errorf(
+			"Patterns match %d files, but new path %q is not a directory", len(matched), to,
+		)
+	}
+
+	var result []string
+
+	for _, file := range matched {
+		if isDir {
+			result = append(result, to+"/"+path.Base(file))
+		} else {
+			result = append(result, to)
+		}
+	}
+
+	return result, nil
+}
+
+// runAppendAction appends text to file, file is created if it doesn't exist
+func runAppendAction(dir, file, text string) error {
+	err := validateTargetPath(file)
+
+	if err != nil {
+		return fmt.Errorf("Invalid file path %q: %w", file, err)
+	}
+
+	err = os.MkdirAll(path.Dir(dir+"/"+file), 0755)
+
+	if err != nil {
+		return err
+	}
+
+	fd, err := os.OpenFile(dir+"/"+file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+
+	_, err = fd.WriteString(text)
+
+	if err != nil {
+		fd.Close()
+		return err
+	}
+
+	return fd.Close()
+}
+
+// matchFiles returns files which match at least one of given globs
+func matchFiles(patterns, files []string) []string {
+	var result []string
+
+	for _, file := range files {
+		for _, pattern := range patterns {
+			if matchGlob(pattern, file) {
+				result = append(result, file)
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+// parseFileMode parses octal file permissions
+func parseFileMode(mode string) (os.FileMode, error) {
+	if varRegex.MatchString(mode) {
+		// Mode will be checked after rendering
+		return 0, nil
+	}
+
+	perms, err := strconv.ParseUint(mode, 8, 32)
+
+	if err != nil || perms > 0777 {
+		return 0, fmt.Errorf("Invalid file mode %q", mode)
+	}
+
+	return os.FileMode(perms), nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// applyActionsToObjects applies changes made by actions to rendered template
+// objects, so rendered template matches files of generated project
+func applyActionsToObjects(tmpl *Template, objects map[string]*RenderedObject) error {
+	if tmpl.Manifest == nil {
+		return nil
+	}
+
+	for i, action := range tmpl.Manifest.Actions {
+		err := applyActionToObjects(action, tmpl.Vars, objects)
+
+		if err != nil {
+			return fmt.Errorf("Action #%d (%s) failed: %w", i+1, action.Action, err)
+		}
+	}
+
+	return nil
+}
+
+// applyActionToObjects applies changes made by single action to rendered objects
+func applyActionToObjects(action *ManifestAction, vars Variables, objects map[string]*RenderedObject) error {
+	var patterns, targets []string
+
+	for _, pattern := range action.Files {
+		patterns = append(patterns, applyVariables(pattern, vars))
+	}
+
+	for target := range objects {
+		targets = append(targets, target)
+	}
+
+	slices.Sort(targets)
+
+	matched := matchFiles(patterns, targets)
+
+	switch action.Action {
+	case ACTION_TYPE_CHMOD:
+		perms, err := parseFileMode(applyVariables(action.Mode, vars))
+
+		if err != nil {
+			return err
+		}
+
+		for _, file := range matched {
+			obj := objects[file].Object
+
+			if !obj.IsLink() {
+				obj.Mode = obj.Mode&^os.ModePerm | perms
+			}
+		}
+
+	case ACTION_TYPE_RENAME:
+		renamed, err := getRenameTargets(applyVariables(action.To, vars), matched)
+
+		if err != nil {
+			return err
+		}
+
+		for i, file := range matched {
+			if objects[renamed[i]] != nil {
+				return fmt.Errorf("Can't rename %s: %s already exists", file, renamed[i])
+			}
+
+			obj := objects[file]
+			obj.Object.Target = renamed[i]
+			objects[renamed[i]] = obj
+
+			delete(objects, file)
+			keepParentDirs(objects, file)
+		}
+
+	case ACTION_TYPE_DELETE:
+		for _, file := range matched {
+			for target := range objects {
+				if target == file || strings.HasPrefix(target, file+"/") {
+					delete(objects, target)
+				}
+			}
+
+			keepParentDirs(objects, file)
+		}
+
+	case ACTION_TYPE_APPEND:
+		file := applyVariables(action.File, vars)
+		text := applyVariables(action.Text, vars)
+
+		if validateTargetPath(file) != nil {
+			return fmt.Errorf("Invalid file path %q", file)
+		}
+
+		if !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+
+		obj := objects[file]
+
+		switch {
+		case obj == nil:
+			objects[file] = &RenderedObject{
+				Object: &TemplateObject{Name: file, Target: file, Mode: 0644},
+				Data:   []byte(text),
+			}
+		case obj.Object.IsDir() || obj.Object.IsLink():
+			return fmt.Errorf("Can't append text to %s", file)
+		default:
+			obj.Data = append(obj.Data, text...)
+		}
+	}
+
+	return nil
+}
+
+// keepParentDirs adds empty directories which are left in project after file
+// was moved or deleted
+func keepParentDirs(objects map[string]*RenderedObject, file string) {
+	for dir := path.Dir(file); dir != "."; dir = path.Dir(dir) {
+		if objects[dir] != nil || hasObjectsInDir(objects, dir) {
+			return
+		}
+
+		objects[dir] = &RenderedObject{
+			Object: &TemplateObject{Name: dir, Target: dir, Mode: os.ModeDir | 0755},
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/errors"
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/options"
+	"github.com/essentialkaos/ek/v13/pluralize"
+	"github.com/essentialkaos/ek/v13/sortutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// readAnswersFile reads variables values from JSON or YAML file
+func readAnswersFile(file string) (Variables, error) {
+	err := fsutil.ValidatePerms("FRS", file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]any)
+	err = readConfigFile(file, &data)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't read answers file: %w", err)
+	}
+
+	result := make(Variables)
+
+	for name, value := range data {
+		if !varNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("Answers file contains invalid variable name %q", name)
+		}
+
+		switch t := value.(type) {
+		case string, bool, int, int64, uint64, float64:
+			result[name] = fmt.Sprint(value)
+		case []any:
+			var items []string
+
+			for _, item := range t {
+				items = append(items, fmt.Sprint(item))
+			}
+
+			result[name] = strings.Join(items, LIST_SEPARATOR)
+		case nil:
+			result[name] = ""
+		default:
+			return nil, fmt.Errorf("Answers file contains unsupported value for variable %q", name)
+		}
+	}
+
+	return result, nil
+}
+
+// parseVarDefinitions parses variables definitions in NAME=value format
+func parseVarDefinitions(defs []string) (Variables, error) {
+	result := make(Variables)
+
+	for _, def := range defs {
+		name, value, ok := strings.Cut(def, "=")
+
+		if !ok || !varNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("Invalid variable definition %q (must be NAME=value)", def)
+		}
+
+		result[name] = value
+	}
+
+	return result, nil
+}
+
+// checkVarDefinitions checks that template has all variables from definitions
+func checkVarDefinitions(defs, vars Variables, info *VariableInfoStore) error {
+	var unknown []string
+
+	for name := range defs {
+		_, isKnown := info.Info[name]
+
+		if !isKnown && !vars.Has(name) {
+			unknown = append(unknown, name)
+		}
+	}
+
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	sortutil.StringsNatural(unknown)
+
+	return fmt.Errorf(
+		"Template doesn't have %s %s defined with %s option",
+		pluralize.Pluralize(len(unknown), "variable", "variables"),
+		strings.Join(unknown, ", "), options.F(OPT_VAR),
+	)
+}
+
+// applyVariablesValues sets variables values from given map and returns error with
+// info about all missing and invalid values
+func applyVariablesValues(vars Variables, info *VariableInfoStore, values Variables) error {
+	errs := errors.NewBundle()
+
+	for _, v := range info.List {
+		if !vars.Has(v) || info.Info[v].IsDynamic {
+			continue
+		}
+
+		varInfo := info.Info[v]
+		value, ok := values[v]
+
+		if !ok || value == "" {
+			value = varInfo.GetDefault(vars)
+		}
+
+		value = varInfo.Canonical(value)
+
+		switch {
+		case value == "" && !varInfo.IsOptional:
+			errs.Add(fmt.Errorf("Variable %s (%s) is not set", v, varInfo.Desc))
+		case !varInfo.IsValid(value):
+			errs.Add(fmt.Errorf("Value %q is not valid for variable %s (%s)", value, v, varInfo.Desc))
+		default:
+			vars[v] = value
+		}
+	}
+
+	if !errs.IsEmpty() {
+		return fmt.Errorf("Can't use given variables values:\n%s", errs.Error("  - "))
+	}
+
+	return nil
+}
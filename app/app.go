@@ -8,9 +8,16 @@ package app
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/essentialkaos/ek/v13/fmtc"
 	"github.com/essentialkaos/ek/v13/fmtutil"
@@ -23,10 +30,10 @@ import (
 	"github.com/essentialkaos/ek/v13/support"
 	"github.com/essentialkaos/ek/v13/support/apps"
 	"github.com/essentialkaos/ek/v13/support/deps"
-	"github.com/essentialkaos/ek/v13/system"
 	"github.com/essentialkaos/ek/v13/terminal"
 	"github.com/essentialkaos/ek/v13/terminal/input"
 	"github.com/essentialkaos/ek/v13/terminal/tty"
+	"github.com/essentialkaos/ek/v13/timeutil"
 	"github.com/essentialkaos/ek/v13/usage"
 	"github.com/essentialkaos/ek/v13/usage/completion/bash"
 	"github.com/essentialkaos/ek/v13/usage/completion/fish"
@@ -46,9 +53,33 @@ const (
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 const (
-	OPT_NO_COLOR = "nc:no-color"
-	OPT_HELP     = "h:help"
-	OPT_VER      = "v:version"
+	CMD_UPDATE = "update"
+	CMD_DIFF   = "diff"
+	CMD_ADD    = "add"
+)
+
+const (
+	FORMAT_TEXT = "text"
+	FORMAT_JSON = "json"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	OPT_TEMPLATES_DIR = "t:templates-dir"
+	OPT_VAR           = "V:var"
+	OPT_ANSWERS       = "A:answers"
+	OPT_YES           = "y:yes"
+	OPT_DRY_RUN       = "D:dry-run"
+	OPT_SHOW          = "S:show"
+	OPT_CONFLICT      = "C:conflict"
+	OPT_REPLAY        = "R:replay"
+	OPT_FORMAT        = "F:format"
+	OPT_NO_HOOKS      = "nh:no-hooks"
+	OPT_TRUST         = "T:trust"
+	OPT_NO_COLOR      = "nc:no-color"
+	OPT_HELP          = "h:help"
+	OPT_VER           = "v:version"
 
 	OPT_VERB_VER     = "vv:verbose-version"
 	OPT_COMPLETION   = "completion"
@@ -58,17 +89,36 @@ const (
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 var optMap = options.Map{
-	OPT_NO_COLOR: {Type: options.BOOL},
-	OPT_HELP:     {Type: options.BOOL},
-	OPT_VER:      {Type: options.MIXED},
+	OPT_TEMPLATES_DIR: {},
+	OPT_VAR:           {Mergeble: true},
+	OPT_ANSWERS:       {},
+	OPT_YES:           {Type: options.BOOL},
+	OPT_DRY_RUN:       {Type: options.BOOL},
+	OPT_SHOW:          {},
+	OPT_CONFLICT:      {},
+	OPT_REPLAY:        {},
+	OPT_FORMAT:        {},
+	OPT_NO_HOOKS:      {Type: options.BOOL},
+	OPT_TRUST:         {Type: options.BOOL},
+	OPT_NO_COLOR:      {Type: options.BOOL},
+	OPT_HELP:          {Type: options.BOOL},
+	OPT_VER:           {Type: options.MIXED},
 
 	OPT_VERB_VER:     {Type: options.BOOL},
 	OPT_COMPLETION:   {},
 	OPT_GENERATE_MAN: {Type: options.BOOL},
 }
 
-// templatesDir is path to directory with templates
-var templatesDir string
+// templatesLayers is list of directories with templates ordered by priority
+var templatesLayers []*TemplatesLayer
+
+// commands is list of supported commands. Templates with the same names are
+// ignored, because they can't be used.
+var commands = []string{CMD_UPDATE, CMD_DIFF, CMD_ADD}
+
+// allowedTargetFiles is list of objects which can exist in target directory of
+// new project. These objects are overwritten if conflict strategy is not set.
+var allowedTargetFiles = []string{".git", ".github", "README.md", "LICENSE"}
 
 // color tags for app name and version
 var colorTagApp, colorTagVer string
@@ -79,6 +129,10 @@ var colorTagApp, colorTagVer string
 func Run(gitRev string, gomod []byte) {
 	preConfigureUI()
 
+	// Values of variables can contain spaces, so we use new line as a separator
+	// for merging --var options
+	options.MergeSymbol = "\n"
+
 	args, errs := options.Parse(optMap)
 
 	if !errs.IsEmpty() {
@@ -110,21 +164,30 @@ func Run(gitRev string, gomod []byte) {
 		os.Exit(0)
 	}
 
-	if !findTemplatesDir() {
+	if !findTemplatesDirs() {
 		os.Exit(1)
 	}
 
 	var err error
 
-	switch len(args) {
-	case 0:
+	switch {
+	case options.Has(OPT_REPLAY):
+		err = replayApp(options.GetS(OPT_REPLAY), args.Get(0).String())
+	case args.Get(0).String() == CMD_UPDATE:
+		err = updateApp(args.Get(1).Clean().String())
+	case args.Get(0).String() == CMD_DIFF:
+		err = diffApp(args.Get(1).Clean().String())
+	case args.Get(0).String() == CMD_ADD:
+		err = addApp(args.Get(1).String(), args.Get(2).Clean().String())
+	case len(args) == 0:
 		err = listTemplates()
-	case 1:
+	case len(args) == 1:
 		err = listTemplateData(args.Get(0).String())
 	default:
 		err = generateApp(
 			args.Get(0).String(),
 			args.Get(1).Clean().String(),
+			nil,
 		)
 	}
 
@@ -160,36 +223,73 @@ func configureUI() {
 	input.NewLine = true
 }
 
-// findTemplatesDir tries to find directory with templates
-func findTemplatesDir() bool {
-	user, err := system.CurrentUser()
+// findTemplatesDirs tries to find directories with templates
+func findTemplatesDirs() bool {
+	layers, err := getTemplatesLayers(options.GetS(OPT_TEMPLATES_DIR))
 
 	if err != nil {
-		terminal.Error("Can't get current user info: %v", err)
+		terminal.Error(err.Error())
 		return false
 	}
 
-	templatesDir = path.Clean(path.Join(user.HomeDir, ".config/scratch"))
+	if len(layers) == 0 {
+		userDir, err := getUserTemplatesDir()
+
+		if err != nil {
+			userDir = "~/.config/scratch"
+		}
 
-	if !fsutil.IsExist(templatesDir) {
 		terminal.Warn("▲ Can't find directory with templates")
-		terminal.Warn("  Create directory ~/.config/scratch and add your templates to it")
+		terminal.Warn("  Create directory %s and add your templates to it", userDir)
 		return false
 	}
 
-	err = fsutil.ValidatePerms("DRX", templatesDir)
+	templatesLayers = layers
+
+	return true
+}
+
+// replayApp generates app using template and variables values from project
+// info file
+func replayApp(file, dir string) error {
+	if dir == "" {
+		return fmt.Errorf("You must define target directory")
+	}
+
+	project, err := readProjectInfo(file)
 
 	if err != nil {
-		terminal.Error(err.Error())
-		return false
+		return err
 	}
 
-	return true
+	if hasTemplate(project.Template) {
+		template, err := getTemplate(project.Template)
+
+		if err != nil {
+			return err
+		}
+
+		revision, _ := getTemplateRevision(template)
+
+		if revision != project.Revision {
+			terminal.Warn("Template %q was changed after project generation\n", project.Template)
+		}
+	}
+
+	return generateApp(project.Template, path.Clean(dir), project)
 }
 
-// generateApp generates app from template
-func generateApp(templateName, dir string) error {
-	err := checkTargetDir(dir)
+// generateApp generates app from template. If project info is given, variables
+// values are taken from it.
+func generateApp(templateName, dir string, project *ProjectInfo) error {
+	strategy := options.GetS(OPT_CONFLICT)
+	err := checkConflictStrategy(strategy)
+
+	if err != nil {
+		return err
+	}
+
+	err = checkTargetDir(dir, strategy != "")
 
 	if err != nil {
 		return err
@@ -207,235 +307,1519 @@ func generateApp(templateName, dir string) error {
 		return err
 	}
 
-	err = readVariablesValues(template.Vars)
+	if len(template.Data) == 0 {
+		return fmt.Errorf("Template %q has no files", templateName)
+	}
+
+	// Dynamic variables can be used in default values, so we must generate them
+	// before reading values of other variables
+	applyDynamicVariables(template.Vars, dir)
+	inferVariables(dir, template.Vars, template.Info)
+
+	if project != nil || options.Has(OPT_VAR) || options.Has(OPT_ANSWERS) {
+		err = setVariablesValues(template.Vars, template.Info, project)
+	} else {
+		err = readVariablesValues(template.Vars, template.Info, nil)
+	}
 
 	if err != nil {
 		return err
 	}
 
-	if !printVariablesInfo(template.Vars) {
-		return nil
+	applyDerivedVariables(template.Vars, template.Info)
+
+	if options.Has(OPT_SHOW) {
+		return showTemplateFile(template, options.GetS(OPT_SHOW))
+	}
+
+	if options.GetB(OPT_DRY_RUN) {
+		printVariablesInfo(template.Vars, template.Info)
+		return printDryRunInfo(template, dir)
+	}
+
+	// Values from project info are used without review
+	if project == nil {
+		ok, err := reviewVariables(template)
+
+		fmtc.NewLine()
+
+		if err != nil || !ok {
+			return nil
+		}
+	}
+
+	withHooks := template.HasHooks() && !options.GetB(OPT_NO_HOOKS)
+
+	// Target directory is created before running pre-generation hooks, so it
+	// must be removed if generation fails
+	newDir := getMissingDir(dir)
+
+	if withHooks {
+		ok, err := checkHooksTrust(template)
+
+		if err != nil || !ok {
+			return err
+		}
+
+		err = runPreHooks(template, dir)
+
+		if err != nil {
+			removeMissingDir(newDir)
+			return err
+		}
 	}
 
 	fmtc.Println("{*}Generating files…{!}\n")
 
-	err = copyTemplateData(template, dir)
+	// Staging directory is created next to target directory, so all missing
+	// parent directories must be created first
+	err = os.MkdirAll(path.Dir(dir), 0755)
+
+	if err != nil {
+		removeMissingDir(newDir)
+		return fmt.Errorf("Can't create target directory: %w", err)
+	}
+
+	report, err := copyTemplateData(template, dir, getConflictHandler(strategy))
 
 	if err != nil {
+		removeMissingDir(newDir)
 		return err
 	}
 
+	if strategy != "" {
+		printGenerationReport(report)
+	}
+
 	fmtc.Println("{g}Files successfully generated!{!}")
 
+	if template.HasActions() {
+		fmtc.Println("\n{*}Running actions…{!}\n")
+
+		// Actions must not change project info
+		files := slices.DeleteFunc(report.Generated(), func(file string) bool {
+			return file == PROJECT_FILE
+		})
+
+		err = runActions(template, dir, files)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if withHooks && len(template.Manifest.Hooks.Post) != 0 {
+		fmtc.Println("\n{*}Running post-generation hooks…{!}\n")
+
+		err = runHooks(template, HOOK_POST, dir)
+
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-// listTemplates renders list of all available templates
-func listTemplates() error {
-	templates, err := getTemplates()
+// addApp generates component from template or its subdirectory into directory of
+// existing project
+func addApp(component, dir string) error {
+	strategy := options.GetS(OPT_CONFLICT)
+	err := checkConflictStrategy(strategy)
 
 	if err != nil {
 		return err
 	}
 
-	if len(templates) == 0 {
-		fmtc.Println("{y}No templates found{!}")
-		return nil
+	templateName, subDir, _ := strings.Cut(component, "/")
+
+	switch {
+	case templateName == "":
+		return fmt.Errorf("You must define template name")
+	case !hasTemplate(templateName):
+		return fmt.Errorf("There is no template with name %q", templateName)
 	}
 
-	fmtc.NewLine()
+	dir, _ = filepath.Abs(dir)
 
-	for _, t := range templates {
-		if len(t.Data) == 0 {
-			fmtc.Printfn(" {s}•{!} %s {s-}(empty){!}", t.Name)
-		} else {
-			fmtc.Printfn(
-				" {s}•{!} %s {s-}(%s){!}",
-				t.Name, pluralize.P("%d %s", len(t.Data), "file", "files"),
-			)
-		}
+	switch {
+	case fsutil.IsLink(dir):
+		return fmt.Errorf("Component directory %s is a symbolic link", dir)
+	case fsutil.IsExist(dir) && !fsutil.IsDir(dir):
+		return fmt.Errorf("%s is not a directory", dir)
 	}
 
-	fmtc.NewLine()
+	err = checkTargetDir(dir, true)
 
-	return nil
-}
+	if err != nil {
+		return err
+	}
 
-// listTemplateData show list of files in template
-func listTemplateData(name string) error {
-	if !hasTemplate(name) {
-		return fmt.Errorf("There is no templates with name %q", name)
+	template, err := getTemplate(templateName)
+
+	if err != nil {
+		return err
 	}
 
-	t, err := getTemplate(name)
+	hasExtras := template.HasActions() || template.HasHooks()
+	err = selectComponent(template, subDir)
 
 	if err != nil {
 		return err
 	}
 
-	sortutil.StringsNatural(t.Data)
+	if hasExtras {
+		fmtc.Println("{s-}Actions and hooks from template are not used for components{!}")
+	}
 
-	fmtc.Printfn(
-		"\n {s-}┌{!} {*}%s{!} {s-}(%s){!}\n {s-}│{!}",
-		t.Name, pluralize.P("%d %s", len(t.Data), "file", "files"),
-	)
+	project, projectDir, err := findProject(dir)
 
-	for i, file := range t.Data {
-		if i+1 != len(t.Data) {
-			fmtc.Print(" {s-}├─{!}")
-		} else {
-			fmtc.Print(" {s-}└─{!}")
-		}
+	if err != nil {
+		return err
+	}
 
-		fileSize := fsutil.GetSize(path.Join(t.Path, file))
+	var saved Variables
 
-		fmtc.Printfn(
-			" %s {s-}(%s){!}",
-			lscolors.ColorizePath(file),
-			fmtutil.PrettySize(fileSize),
-		)
+	if project != nil {
+		fmtc.Printfn("{s-}Using saved answers from %s{!}", projectDir+"/"+PROJECT_FILE)
+		saved = project.Vars
 	}
 
-	fmtc.NewLine()
+	// Component is a part of project, so variables are generated for the whole
+	// project
+	applyDynamicVariables(template.Vars, projectDir)
+	inferVariables(projectDir, template.Vars, template.Info)
 
-	for _, v := range knownVars.List {
-		_, ok := t.Vars[v]
+	if options.Has(OPT_VAR) || options.Has(OPT_ANSWERS) {
+		err = setVariablesValues(template.Vars, template.Info, project)
+	} else {
+		err = readVariablesValues(template.Vars, template.Info, saved)
+	}
 
-		if ok {
-			fmtc.Printfn(" {s-}•{!} {s}%s — {&}%s{!}", v, knownVars.Info[v].Desc)
-		}
+	if err != nil {
+		return err
+	}
+
+	applyDerivedVariables(template.Vars, template.Info)
+
+	if options.Has(OPT_SHOW) {
+		return showTemplateFile(template, options.GetS(OPT_SHOW))
+	}
+
+	if options.GetB(OPT_DRY_RUN) {
+		printVariablesInfo(template.Vars, template.Info)
+		return printDryRunInfo(template, dir)
 	}
 
+	ok, err := reviewVariables(template)
+
 	fmtc.NewLine()
 
+	if err != nil || !ok {
+		return nil
+	}
+
+	// Staging directory for component is created inside component directory
+	newDir := getMissingDir(dir)
+	err = os.MkdirAll(dir, 0755)
+
+	if err != nil {
+		removeMissingDir(newDir)
+		return fmt.Errorf("Can't create component directory: %w", err)
+	}
+
+	fmtc.Println("{*}Generating files…{!}\n")
+
+	if strategy == "" {
+		// Component directory is a part of existing project, so any conflict is
+		// an error
+		strategy = CONFLICT_FAIL
+	}
+
+	report, err := copyTemplateData(template, dir, getConflictHandler(strategy))
+
+	if err != nil {
+		removeMissingDir(newDir)
+		return err
+	}
+
+	printGenerationReport(report)
+
+	fmtc.Println("{g}Component successfully generated!{!}")
+
 	return nil
 }
 
-// readVariablesValues reads values for variables from template
-func readVariablesValues(vars Variables) error {
-	var curVar, totalVar int
+// runPreHooks creates target directory and runs pre-generation hooks in it
+func runPreHooks(tmpl *Template, dir string) error {
+	if len(tmpl.Manifest.Hooks.Pre) == 0 {
+		return nil
+	}
 
-	fmtc.NewLine()
+	err := os.MkdirAll(dir, 0755)
 
-	totalVar = vars.Count()
+	if err != nil {
+		return fmt.Errorf("Can't create target directory: %w", err)
+	}
 
-	for _, v := range knownVars.List {
-		if !vars.Has(v) {
-			continue
-		}
+	fmtc.Println("{*}Running pre-generation hooks…{!}\n")
 
-		curVar++
+	return runHooks(tmpl, HOOK_PRE, dir)
+}
 
-		for {
-			fmtc.Printfn("{s-}[%d/%d]{!} {c}%s:{!}", curVar, totalVar, knownVars.Info[v].Desc)
-			value, err := input.Read("", input.NotEmpty)
+// checkHooksTrust checks if user trusts template hooks and asks for
+// confirmation if it doesn't
+func checkHooksTrust(tmpl *Template) (bool, error) {
+	store, err := readTrustStore()
 
-			if err != nil {
-				os.Exit(1)
-			}
+	if err != nil {
+		return false, err
+	}
 
-			if !knownVars.Info[v].IsValid(value) {
-				terminal.Warn("%q is not a valid value for this variable\n", value)
-				continue
-			}
+	if store.IsTrusted(tmpl) {
+		return true, nil
+	}
 
-			vars[v] = value
+	if !options.GetB(OPT_TRUST) {
+		if options.GetB(OPT_YES) {
+			return false, fmt.Errorf(
+				"Template %q contains hooks which are not trusted (use %s to trust them or %s to skip them)",
+				tmpl.Name, options.F(OPT_TRUST), options.F(OPT_NO_HOOKS),
+			)
+		}
 
-			break
+		fmtc.Printfn("{y}▲ Template {*}%s{!*} wants to run these commands:{!}\n", tmpl.Name)
+		printHooksInfo(tmpl)
+
+		ok, err := input.ReadAnswer("Trust this template and run its commands?", "n")
+
+		fmtc.NewLine()
+
+		if err != nil || !ok {
+			fmtc.Printfn(
+				"{s}Generation canceled. Use %s option to generate files without running hooks.{!}",
+				options.F(OPT_NO_HOOKS),
+			)
+
+			return false, nil
 		}
 	}
 
-	return nil
+	store.Trust(tmpl)
+
+	return true, writeTrustStore(store)
 }
 
-// printVariablesInfo prints defined variables
-func printVariablesInfo(vars Variables) bool {
-	fmtutil.Separator(false)
+// printActionsInfo prints list of built-in actions. If vars is nil, variables
+// are not substituted.
+func printActionsInfo(actions []*ManifestAction, vars Variables) {
+	for _, action := range actions {
+		desc := action.Describe(vars)
 
-	for _, v := range knownVars.List {
-		if !vars.Has(v) {
-			continue
+		if desc == "" {
+			fmtc.Printfn(" {s-}•{!} %s", action.Action)
+		} else {
+			fmtc.Printfn(" {s-}•{!} %s {s-}(%s){!}", action.Action, desc)
 		}
-
-		fmtc.Printfn("  {*}%-16s{!} %s", v+":", vars[v])
 	}
 
-	fmtutil.Separator(false)
-
 	fmtc.NewLine()
+}
 
-	ok, err := input.ReadAnswer("Everything is ok?", "y")
+// printHooksInfo prints template hooks with variables values
+func printHooksInfo(tmpl *Template) {
+	for _, stage := range hookStages {
+		for _, hook := range tmpl.Manifest.Hooks.Get(stage) {
+			fmtc.Printfn(
+				" {s-}•{!} {s}%s:{!} %s {s-}(%s){!}",
+				stage, formatCommand(hook.Command(tmpl.Vars)),
+				timeutil.PrettyDuration(hook.GetTimeout()),
+			)
+		}
+	}
 
 	fmtc.NewLine()
-
-	return err == nil && ok
 }
 
-// checkTargetDir checks target dir
-func checkTargetDir(dir string) error {
-	if !fsutil.IsExist(dir) {
-		return os.Mkdir(dir, 0755)
+// updateApp updates project generated from template to the latest template
+// revision
+func updateApp(dir string) error {
+	dir, _ = filepath.Abs(dir)
+
+	project, err := readProjectInfo(dir + "/" + PROJECT_FILE)
+
+	if err != nil {
+		return err
 	}
 
-	err := fsutil.ValidatePerms("DRWX", dir)
+	if !hasTemplate(project.Template) {
+		return fmt.Errorf("There is no template with name %q", project.Template)
+	}
+
+	newTmpl, err := getTemplate(project.Template)
 
 	if err != nil {
 		return err
 	}
 
-	objects := fsutil.List(dir, false, fsutil.ListingFilter{
-		NotMatchPatterns: []string{".git", ".github", "README.md", "LICENSE"},
-	})
+	revision, err := getTemplateRevision(newTmpl)
 
-	if len(objects) != 0 {
-		return fmt.Errorf("Target directory is not empty")
+	if err != nil {
+		return fmt.Errorf("Can't calculate template revision: %w", err)
 	}
 
-	return nil
-}
+	if revision == project.Revision && !options.Has(OPT_VAR) {
+		fmtc.Println("{g}Project is already up to date{!}")
+		return nil
+	}
 
-// ////////////////////////////////////////////////////////////////////////////////// //
+	oldTmpl, cleanup, err := getTemplateRevisionData(newTmpl, project)
 
-// printCompletion prints completion for given shell
-func printCompletion() int {
-	info := genUsage()
+	if err != nil {
+		return err
+	}
 
-	switch options.GetS(OPT_COMPLETION) {
-	case "bash":
-		fmt.Print(bash.Generate(info, APP))
-	case "fish":
-		fmt.Print(fish.Generate(info, APP))
-	case "zsh":
-		fmt.Print(zsh.Generate(info, optMap, APP))
-	default:
-		return 1
+	defer cleanup()
+
+	err = setProjectVariables(newTmpl, project, dir)
+
+	if err != nil {
+		return err
 	}
 
-	return 0
-}
+	// Old revision is rendered with old values and the same dynamic values
+	applyDynamicVariables(oldTmpl.Vars, dir)
+	applySavedDynamicVariables(oldTmpl.Vars, oldTmpl.Info, project)
 
-// printMan prints man page
-func printMan() {
-	fmt.Println(man.Generate(genUsage(), genAbout("")))
-}
+	for v := range oldTmpl.Vars {
+		switch {
+		case !oldTmpl.Info.Info[v].IsDynamic:
+			oldTmpl.Vars[v] = project.Vars[v]
+		case newTmpl.Vars.Has(v):
+			oldTmpl.Vars[v] = newTmpl.Vars[v]
+		}
+	}
 
-// genUsage generates usage info
-func genUsage() *usage.Info {
-	info := usage.NewInfo("", "template", "target-dir")
+	applyDerivedVariables(oldTmpl.Vars, oldTmpl.Info)
 
-	info.AddOption(OPT_NO_COLOR, "Disable colors in output")
-	info.AddOption(OPT_HELP, "Show this help message")
-	info.AddOption(OPT_VER, "Show version")
+	oldObjects, err := renderTemplateObjects(oldTmpl)
 
-	info.AddExample("package", "List files in template \"package\"")
-	info.AddExample(
-		"package .",
-		"Generate files based on template \"package\" in current directory",
-	)
-	info.AddExample(
+	if err != nil {
+		return fmt.Errorf("Can't render previous template revision: %w", err)
+	}
+
+	newObjects, err := renderTemplateObjects(newTmpl)
+
+	if err != nil {
+		return err
+	}
+
+	actions := getUpdateActions(oldObjects, newObjects, dir)
+
+	fmtc.NewLine()
+
+	if len(actions) == 0 {
+		fmtc.Println("{s}Template changes don't affect project files{!}\n")
+	} else {
+		printUpdateActions(actions)
+	}
+
+	if options.GetB(OPT_DRY_RUN) {
+		fmtc.Println("{s}Dry run, no files were changed{!}")
+		return nil
+	}
+
+	ok, err := readConfirmation("Update project?", "y")
+
+	fmtc.NewLine()
+
+	if err != nil || !ok {
+		return nil
+	}
+
+	err = applyUpdateActions(newTmpl, actions, dir)
+
+	if err != nil {
+		return err
+	}
+
+	var conflicts int
+
+	for _, action := range actions {
+		if action.Action == UPDATE_CONFLICT || action.Action == UPDATE_REJECT {
+			conflicts++
+		}
+	}
+
+	if conflicts != 0 {
+		fmtc.Printfn(
+			"{y}Project updated, %s must be resolved manually{!}",
+			pluralize.P("%d %s", conflicts, "file", "files"),
+		)
+	} else {
+		fmtc.Println("{g}Project successfully updated!{!}")
+	}
+
+	return nil
+}
+
+// diffApp compares project with its template and prints report about
+// differences
+func diffApp(dir string) error {
+	format := options.GetS(OPT_FORMAT)
+
+	if format != "" && format != FORMAT_TEXT && format != FORMAT_JSON {
+		return fmt.Errorf(
+			"Unsupported output format %q (supported: %s, %s)",
+			format, FORMAT_TEXT, FORMAT_JSON,
+		)
+	}
+
+	dir, _ = filepath.Abs(dir)
+
+	project, err := readProjectInfo(dir + "/" + PROJECT_FILE)
+
+	if err != nil {
+		return err
+	}
+
+	if !hasTemplate(project.Template) {
+		return fmt.Errorf("There is no template with name %q", project.Template)
+	}
+
+	tmpl, err := getTemplate(project.Template)
+
+	if err != nil {
+		return err
+	}
+
+	err = setProjectVariables(tmpl, project, dir)
+
+	if err != nil {
+		return err
+	}
+
+	objects, err := renderTemplateObjects(tmpl)
+
+	if err != nil {
+		return err
+	}
+
+	report, err := getDriftReport(objects, dir)
+
+	if err != nil {
+		return fmt.Errorf("Can't compare project with template: %w", err)
+	}
+
+	report.Template = project.Template
+	report.Revision = project.Revision
+	report.TemplateRevision, err = getTemplateRevision(tmpl)
+
+	if err != nil {
+		return fmt.Errorf("Can't calculate template revision: %w", err)
+	}
+
+	if format == FORMAT_JSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(data))
+
+		return nil
+	}
+
+	printDriftReport(report, dir)
+
+	return nil
+}
+
+// setProjectVariables sets variables values from project info
+func setProjectVariables(tmpl *Template, project *ProjectInfo, dir string) error {
+	applyDynamicVariables(tmpl.Vars, dir)
+	applySavedDynamicVariables(tmpl.Vars, tmpl.Info, project)
+
+	err := setVariablesValues(tmpl.Vars, tmpl.Info, project)
+
+	if err != nil {
+		return err
+	}
+
+	applyDerivedVariables(tmpl.Vars, tmpl.Info)
+
+	return nil
+}
+
+// printUpdateActions prints list of changes in project
+func printUpdateActions(actions []*UpdateAction) {
+	groups := make(map[string][]string)
+
+	for _, action := range actions {
+		file := action.Target
+
+		switch action.Action {
+		case UPDATE_REJECT:
+			file += " → " + path.Base(action.Target) + REJECT_SUFFIX
+		case UPDATE_CONFLICT, UPDATE_KEEP:
+			file += " (" + action.Reason + ")"
+		}
+
+		groups[action.Action] = append(groups[action.Action], file)
+	}
+
+	printReportGroup("Created", "{g}", groups[UPDATE_CREATE])
+	printReportGroup("Updated", "{g}", groups[UPDATE_UPDATE])
+	printReportGroup("Merged", "{c}", groups[UPDATE_MERGE])
+	printReportGroup("Merged with conflicts", "{r}", groups[UPDATE_CONFLICT])
+	printReportGroup("Rejected", "{r}", groups[UPDATE_REJECT])
+	printReportGroup("Deleted", "{y}", groups[UPDATE_DELETE])
+	printReportGroup("Kept", "{s}", groups[UPDATE_KEEP])
+}
+
+// printDriftReport prints tree of project files with their statuses and diffs
+// for modified files
+func printDriftReport(report *DriftReport, dir string) {
+	fmtc.Printfn(
+		"\n {s-}┌{!} {*}%s{!} {s-}(template: %s){!}\n {s-}│{!}",
+		path.Base(dir), report.Template,
+	)
+
+	printDriftTree(buildDriftTree(report.Files), " ")
+
+	fmtc.NewLine()
+
+	for _, entry := range report.Files {
+		if entry.Diff != "" {
+			printDiff(entry.Diff)
+			fmtc.NewLine()
+		}
+	}
+
+	if report.Revision != report.TemplateRevision {
+		fmtc.Println("{s}Template was changed after project generation{!}\n")
+	}
+
+	if !report.HasChanges() {
+		fmtc.Println("{g}Project is identical to template{!}\n")
+		return
+	}
+
+	fmtc.Printfn(
+		"{r}%d missing{!} {s-}•{!} {g}%d added{!} {s-}•{!} {y}%d modified{!} {s-}•{!} {s}%d identical{!}\n",
+		report.Summary.Missing, report.Summary.Added,
+		report.Summary.Modified, report.Summary.Identical,
+	)
+}
+
+// buildDriftTree converts list of entries to tree
+func buildDriftTree(entries []*DriftEntry) *DriftNode {
+	root := &DriftNode{}
+
+	for _, entry := range entries {
+		node := root
+
+		for _, name := range strings.Split(strings.TrimSuffix(entry.Path, "/"), "/") {
+			node = node.Child(name)
+		}
+
+		node.Entry = entry
+	}
+
+	return root
+}
+
+// printDriftTree prints tree of project files
+func printDriftTree(node *DriftNode, prefix string) {
+	for i, child := range node.Children {
+		isLast := i+1 == len(node.Children)
+
+		if isLast {
+			fmtc.Print(prefix + "{s-}└─{!} ")
+		} else {
+			fmtc.Print(prefix + "{s-}├─{!} ")
+		}
+
+		if child.IsDir() {
+			fmtc.Printf("%s/", lscolors.ColorizePath(child.Name))
+		} else {
+			fmtc.Printf("%s", lscolors.ColorizePath(child.Name))
+		}
+
+		if child.Entry != nil {
+			printDriftStatus(child.Entry)
+		}
+
+		fmtc.NewLine()
+
+		if isLast {
+			printDriftTree(child, prefix+"   ")
+		} else {
+			printDriftTree(child, prefix+"{s-}│{!}  ")
+		}
+	}
+}
+
+// printDriftStatus prints status of project file
+func printDriftStatus(entry *DriftEntry) {
+	switch entry.Status {
+	case DRIFT_MISSING:
+		fmtc.Print(" {r}• missing{!}")
+	case DRIFT_ADDED:
+		fmtc.Print(" {g}• added{!}")
+	case DRIFT_MODIFIED:
+		fmtc.Print(" {y}• modified{!}")
+	case DRIFT_IDENTICAL:
+		fmtc.Print(" {s-}• identical{!}")
+	}
+
+	if entry.Reason != "" {
+		fmtc.Printf(" {s-}(%s){!}", entry.Reason)
+	}
+}
+
+// listTemplates renders list of all available templates
+func listTemplates() error {
+	templates, errs := getTemplates()
+
+	// Broken template must not hide other templates
+	for _, err := range errs {
+		terminal.Warn("▲ %v", err)
+	}
+
+	if len(templates) == 0 {
+		if len(errs) != 0 {
+			fmtc.NewLine()
+		}
+
+		fmtc.Println("{y}No templates found{!}")
+		return nil
+	}
+
+	for _, layer := range templatesLayers {
+		for _, name := range commands {
+			if fsutil.IsDir(layer.Path + "/" + name) {
+				terminal.Warn(
+					"▲ Template %q from %s is ignored, because %q is a command name",
+					name, layer.Path, name,
+				)
+			}
+		}
+	}
+
+	fmtc.NewLine()
+
+	for _, t := range templates {
+		if len(t.Data) == 0 {
+			fmtc.Printf(" {s}•{!} %s {s-}(empty){!}", t.Name)
+		} else {
+			fmtc.Printf(
+				" {s}•{!} %s {s-}(%s){!}",
+				t.Name, pluralize.P("%d %s", len(t.Data), "file", "files"),
+			)
+		}
+
+		fmtc.Printfn(" {s-}[%s]{!}", formatTemplateLayer(t))
+	}
+
+	fmtc.NewLine()
+
+	return nil
+}
+
+// listTemplateData show list of files in template
+func listTemplateData(name string) error {
+	if !hasTemplate(name) {
+		return fmt.Errorf("There is no templates with name %q", name)
+	}
+
+	t, err := getTemplate(name)
+
+	if err != nil {
+		return err
+	}
+
+	sortutil.StringsNatural(t.Data)
+
+	fmtc.Printf(
+		"\n {s-}┌{!} {*}%s{!} {s-}(%s) [%s]{!}",
+		t.Name, pluralize.P("%d %s", len(t.Data), "file", "files"),
+		formatTemplateLayer(t),
+	)
+
+	if len(t.Manifest.GetExtends()) != 0 {
+		fmtc.Printf(" {s-}extends %s{!}", strings.Join(t.Manifest.GetExtends(), ", "))
+	}
+
+	fmtc.Println("\n {s-}│{!}")
+
+	for i, file := range t.Data {
+		if i+1 != len(t.Data) {
+			fmtc.Print(" {s-}├─{!}")
+		} else {
+			fmtc.Print(" {s-}└─{!}")
+		}
+
+		filePath := t.SourcePath(file)
+
+		switch {
+		case fsutil.IsLink(filePath):
+			link, _ := os.Readlink(filePath)
+			fmtc.Printf(" %s {s-}→ %s{!}", lscolors.ColorizePath(file), link)
+
+		case fsutil.IsDir(filePath):
+			fmtc.Printf(" %s/ {s-}(empty directory){!}", lscolors.ColorizePath(file))
+
+		default:
+			fmtc.Printf(
+				" %s {s-}(%s){!}",
+				lscolors.ColorizePath(file),
+				fmtutil.PrettySize(fsutil.GetSize(filePath)),
+			)
+		}
+
+		if t.Sources[file] != nil {
+			fmtc.Printf(" {c}• from %s{!}", t.Sources[file].Name)
+		}
+
+		if t.Overrides[file] != "" {
+			fmtc.Printf(" {y}• overrides %s{!}", t.Overrides[file])
+		}
+
+		for _, rule := range t.GetRules(file) {
+			fmtc.Printf(" {m}• %s{!}", rule)
+		}
+
+		fmtc.NewLine()
+	}
+
+	fmtc.NewLine()
+
+	for _, v := range t.Info.List {
+		_, ok := t.Vars[v]
+
+		if !ok {
+			continue
+		}
+
+		varInfo := t.Info.Info[v]
+
+		switch varInfo.Type {
+		case VAR_TYPE_CHOICE:
+			fmtc.Printfn(
+				" {s-}•{!} {s}%s — {&}%s{!} {s-}(%s){!}", v, varInfo.Desc,
+				strings.Join(varInfo.Options, " / "),
+			)
+		case "", VAR_TYPE_STRING:
+			fmtc.Printfn(" {s-}•{!} {s}%s — {&}%s{!}", v, varInfo.Desc)
+		default:
+			fmtc.Printfn(" {s-}•{!} {s}%s — {&}%s{!} {s-}(%s){!}", v, varInfo.Desc, varInfo.Type)
+		}
+	}
+
+	var automatic []string
+
+	for v := range t.Vars {
+		if t.Info.Info[v].IsDynamic {
+			automatic = append(automatic, v)
+		}
+	}
+
+	if len(automatic) != 0 {
+		sortutil.StringsNatural(automatic)
+
+		fmtc.Println("\n {*}Automatic:{!}")
+
+		for _, v := range automatic {
+			fmtc.Printfn(" {s-}•{!} {s}%s — {&}%s{!}", v, t.Info.Info[v].Desc)
+		}
+	}
+
+	if len(t.Partials) != 0 {
+		fmtc.Println("\n {*}Partials:{!}")
+
+		for _, name := range getPartialsNames(t) {
+			if t.Partials[name] == t.Path {
+				fmtc.Printfn(" {s-}•{!} {s}%s{!}", name)
+			} else {
+				fmtc.Printfn(" {s-}•{!} {s}%s{!} {s-}(%s){!}", name, t.Partials[name])
+			}
+		}
+	}
+
+	if t.HasActions() {
+		fmtc.Println("\n {*}Actions:{!}")
+
+		for _, action := range t.Manifest.Actions {
+			desc := action.Describe(nil)
+
+			if desc == "" {
+				fmtc.Printfn(" {s-}•{!} {s}%s{!}", action.Action)
+			} else {
+				fmtc.Printfn(" {s-}•{!} {s}%s{!} {s-}(%s){!}", action.Action, desc)
+			}
+		}
+	}
+
+	if t.HasHooks() {
+		fmtc.Println("\n {*}Hooks:{!}")
+
+		for _, stage := range hookStages {
+			for _, hook := range t.Manifest.Hooks.Get(stage) {
+				fmtc.Printfn(" {s-}•{!} {s}%s:{!} %s", stage, hook.Run)
+			}
+		}
+	}
+
+	fmtc.NewLine()
+
+	return nil
+}
+
+// readVariablesValues reads values for variables from template. Variables with
+// valid values in saved map are not requested.
+func readVariablesValues(vars Variables, info *VariableInfoStore, saved Variables) error {
+	var curVar, totalVar int
+
+	fmtc.NewLine()
+
+	known := make(map[string]bool)
+
+	for v, value := range saved {
+		if !vars.Has(v) || info.Info[v].IsDynamic {
+			continue
+		}
+
+		value = info.Info[v].Canonical(value)
+
+		if (value != "" || info.Info[v].IsOptional) && info.Info[v].IsValid(value) {
+			vars[v], known[v] = value, true
+		}
+	}
+
+	totalVar = vars.Count(info) - len(known)
+
+	for _, v := range info.List {
+		if !vars.Has(v) || info.Info[v].IsDynamic || known[v] {
+			continue
+		}
+
+		curVar++
+
+		varInfo := info.Info[v]
+		varInfo.Default = varInfo.GetDefault(vars)
+
+		vars[v] = promptVariable(fmt.Sprintf("[%d/%d]", curVar, totalVar), varInfo)
+	}
+
+	return nil
+}
+
+// promptVariable reads value of variable until user enters valid value
+func promptVariable(prefix string, varInfo VariableInfo) string {
+	for {
+		fmtc.Printf("{s-}%s{!} {c}%s:{!}", prefix, varInfo.Desc)
+
+		if varInfo.Default != "" {
+			fmtc.Printf(" {s-}(default: %s){!}", varInfo.Default)
+		}
+
+		fmtc.NewLine()
+
+		value, err := readVariableValue(varInfo)
+
+		if err != nil {
+			os.Exit(1)
+		}
+
+		if value == "" {
+			value = varInfo.Default
+		}
+
+		value = varInfo.Canonical(value)
+
+		if !varInfo.IsValid(value) {
+			terminal.Warn("%q is not a valid value for this variable\n", value)
+			continue
+		}
+
+		return value
+	}
+}
+
+// readConfirmation asks user for confirmation. If --yes option is set, question
+// is answered automatically.
+func readConfirmation(question, defaultAnswer string) (bool, error) {
+	// AlwaysYes is set only for confirmation, otherwise it affects answers for
+	// bool variables
+	input.AlwaysYes = options.GetB(OPT_YES)
+	defer func() { input.AlwaysYes = false }()
+
+	return input.ReadAnswer(question, defaultAnswer)
+}
+
+// reviewVariables shows values of variables and allows user to change any of them
+// before generation. It returns false if user cancelled generation.
+func reviewVariables(tmpl *Template) (bool, error) {
+	for {
+		editable := printVariablesInfo(tmpl.Vars, tmpl.Info)
+
+		if options.GetB(OPT_YES) || len(editable) == 0 {
+			return readConfirmation("Everything is ok?", "y")
+		}
+
+		answer, err := input.Read("Everything is ok? {s}(Y/n or number of variable to change){!}")
+
+		if err != nil {
+			return false, err
+		}
+
+		switch strings.ToUpper(strings.TrimSpace(answer)) {
+		case "", "Y":
+			return true, nil
+		case "N":
+			return false, nil
+		}
+
+		index, err := strconv.Atoi(strings.TrimSpace(answer))
+
+		if err != nil || index < 1 || index > len(editable) {
+			terminal.Warn("Please enter Y, N or number of variable (1-%d)\n", len(editable))
+			continue
+		}
+
+		v := editable[index-1]
+		varInfo := tmpl.Info.Info[v]
+		varInfo.Default = tmpl.Vars[v]
+
+		fmtc.NewLine()
+
+		tmpl.Vars[v] = promptVariable("[edit]", varInfo)
+
+		// Values of derived variables may depend on changed variable
+		applyDerivedVariables(tmpl.Vars, tmpl.Info)
+
+		fmtc.NewLine()
+	}
+}
+
+// readVariableValue reads value for variable depending on its type
+func readVariableValue(info VariableInfo) (string, error) {
+	switch info.Type {
+	case VAR_TYPE_BOOL:
+		var defaultAnswer string
+
+		switch info.Default {
+		case "true":
+			defaultAnswer = "y"
+		case "false":
+			defaultAnswer = "n"
+		}
+
+		ok, err := input.ReadAnswer("", defaultAnswer)
+
+		return fmt.Sprint(ok), err
+
+	case VAR_TYPE_CHOICE:
+		for i, option := range info.Options {
+			fmtc.Printfn("  {s}%d){!} %s", i+1, option)
+		}
+
+	case VAR_TYPE_INT:
+		switch {
+		case info.Min != nil && info.Max != nil:
+			fmtc.Printfn("{s-}Integer from %d to %d{!}", *info.Min, *info.Max)
+		case info.Min != nil:
+			fmtc.Printfn("{s-}Integer greater than or equal to %d{!}", *info.Min)
+		case info.Max != nil:
+			fmtc.Printfn("{s-}Integer less than or equal to %d{!}", *info.Max)
+		}
+
+	case VAR_TYPE_LIST:
+		return readListValue(info)
+	}
+
+	if info.IsOptional || info.Default != "" {
+		return input.Read("")
+	}
+
+	return input.Read("", input.NotEmpty)
+}
+
+// readListValue reads items of list variable. Items can be entered as
+// comma-separated list or one by one until empty input.
+func readListValue(info VariableInfo) (string, error) {
+	fmtc.Println("{s-}Enter comma-separated list or one item per line (empty line to finish){!}")
+
+	var items []string
+
+	for {
+		value, err := input.Read("")
+
+		if err != nil {
+			return "", err
+		}
+
+		items = append(items, splitList(value)...)
+
+		if value == "" || strings.Contains(value, ",") {
+			break
+		}
+	}
+
+	return strings.Join(items, LIST_SEPARATOR), nil
+}
+
+// setVariablesValues sets values for variables from project info, answers file
+// and options
+func setVariablesValues(vars Variables, info *VariableInfoStore, project *ProjectInfo) error {
+	values := make(Variables)
+
+	if project != nil {
+		maps.Copy(values, project.Vars)
+	}
+
+	if options.Has(OPT_ANSWERS) {
+		answers, err := readAnswersFile(options.GetS(OPT_ANSWERS))
+
+		if err != nil {
+			return err
+		}
+
+		maps.Copy(values, answers)
+	}
+
+	defs, err := parseVarDefinitions(options.Split(OPT_VAR))
+
+	if err != nil {
+		return err
+	}
+
+	err = checkVarDefinitions(defs, vars, info)
+
+	if err != nil {
+		return err
+	}
+
+	maps.Copy(values, defs)
+
+	return applyVariablesValues(vars, info, values)
+}
+
+// printVariablesInfo prints defined variables and returns names of variables
+// which can be changed in numbered order
+func printVariablesInfo(vars Variables, info *VariableInfoStore) []string {
+	var editable []string
+
+	fmtutil.Separator(false)
+
+	for _, v := range info.List {
+		if !vars.Has(v) {
+			continue
+		}
+
+		if info.Info[v].IsDynamic {
+			fmtc.Printf("      {*}%-16s{!} %s", v+":", vars[v])
+		} else {
+			editable = append(editable, v)
+			fmtc.Printf("  {s-}%2d.{!} {*}%-16s{!} %s", len(editable), v+":", vars[v])
+		}
+
+		if vars[v] != "" && vars[v] == info.Info[v].Inferred {
+			fmtc.Print(" {s-}(inferred){!}")
+		}
+
+		fmtc.NewLine()
+	}
+
+	fmtutil.Separator(false)
+
+	fmtc.NewLine()
+
+	return editable
+}
+
+// printDryRunInfo prints tree of files which will be generated from template
+func printDryRunInfo(tmpl *Template, dir string) error {
+	objects, err := getTemplateObjects(tmpl)
+
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return sortutil.NaturalLess(objects[i].Target, objects[j].Target)
+	})
+
+	fmtc.Printfn(
+		" {s-}┌{!} {*}%s{!} {s-}(%s){!}\n {s-}│{!}",
+		dir, pluralize.P("%d %s", len(objects), "file", "files"),
+	)
+
+	for i, obj := range objects {
+		if i+1 != len(objects) {
+			fmtc.Print(" {s-}├─{!}")
+		} else {
+			fmtc.Print(" {s-}└─{!}")
+		}
+
+		switch {
+		case obj.IsLink():
+			fmtc.Printfn(" %s {s-}→ %s{!}", lscolors.ColorizePath(obj.Target), obj.Link)
+
+		case obj.IsDir():
+			fmtc.Printfn(" %s/ {s-}(empty directory){!}", lscolors.ColorizePath(obj.Target))
+
+		default:
+			rendered, source, err := renderTemplateFileToBuffer(tmpl, obj)
+
+			if err != nil {
+				return fmt.Errorf("Can't render file %s: %w", obj.Name, err)
+			}
+
+			fmtc.Printf(
+				" %s {s-}(%s){!}",
+				lscolors.ColorizePath(obj.Target),
+				fmtutil.PrettySize(len(rendered)),
+			)
+
+			if !bytes.Equal(rendered, source) {
+				fmtc.Print(" {c}• substituted{!}")
+			}
+
+			if fsutil.IsExist(dir + "/" + obj.Target) {
+				fmtc.Print(" {y}• exists{!}")
+			}
+
+			fmtc.NewLine()
+		}
+	}
+
+	var excluded int
+
+	for _, file := range tmpl.Data {
+		if !tmpl.IsIncluded(file) {
+			excluded++
+		}
+	}
+
+	if excluded > 0 {
+		fmtc.Printfn(
+			"\n{s}%s excluded by template rules{!}",
+			pluralize.P("%d %s", excluded, "file", "files"),
+		)
+	}
+
+	fmtc.NewLine()
+
+	if tmpl.HasActions() {
+		fmtc.Println("{*}Actions:{!}")
+		printActionsInfo(tmpl.Manifest.Actions, tmpl.Vars)
+	}
+
+	if tmpl.HasHooks() && !options.GetB(OPT_NO_HOOKS) {
+		fmtc.Println("{*}Hooks:{!}")
+		printHooksInfo(tmpl)
+	}
+
+	fmtc.Println("{s}Dry run, no files were created{!}")
+
+	return nil
+}
+
+// showTemplateFile prints rendered file from template
+func showTemplateFile(tmpl *Template, name string) error {
+	objects, err := getTemplateObjects(tmpl)
+
+	if err != nil {
+		return err
+	}
+
+	name = path.Clean(name)
+
+	for _, obj := range objects {
+		if obj.Target != name && obj.Name != name {
+			continue
+		}
+
+		if obj.IsDir() || obj.IsLink() {
+			return fmt.Errorf("%s is not a regular file", name)
+		}
+
+		return renderTemplateFile(tmpl, obj, os.Stdout)
+	}
+
+	return fmt.Errorf("There is no file %q in template %q", name, tmpl.Name)
+}
+
+// getConflictHandler returns handler for conflicts with existing files
+func getConflictHandler(strategy string) ConflictHandler {
+	switch strategy {
+	case "":
+		return overwriteAllowedFiles
+	case CONFLICT_FAIL:
+		return nil
+	case CONFLICT_PROMPT:
+		return promptConflictAction
+	}
+
+	return func(c *Conflict) (string, error) {
+		return strategy, nil
+	}
+}
+
+// overwriteAllowedFiles overwrites objects which can exist in target directory of
+// new project and fails on any other conflict
+func overwriteAllowedFiles(c *Conflict) (string, error) {
+	name, _, _ := strings.Cut(c.Object.Target, "/")
+
+	if !slices.Contains(allowedTargetFiles, name) {
+		return "", fmt.Errorf("File %s already exists", c.Object.Target)
+	}
+
+	return ACTION_OVERWRITE, nil
+}
+
+// promptConflictAction shows difference between existing and rendered file and
+// asks user what to do with it
+func promptConflictAction(c *Conflict) (string, error) {
+	fmtc.Printfn("{y}▲ File {*}%s{!*} already exists{!}\n", c.Object.Target)
+
+	current, _ := os.ReadFile(c.File)
+
+	switch {
+	case c.Rendered == nil || !fsutil.IsRegular(c.File):
+		fmtc.Println("{s}Existing object has a different type{!}")
+	case bytes.IndexByte(current, 0) != -1 || bytes.IndexByte(c.Rendered, 0) != -1:
+		fmtc.Println("{s}Binary files differ{!}")
+	default:
+		printDiff(unifiedDiff(current, c.Rendered, "current/"+c.Object.Target, "template/"+c.Object.Target))
+	}
+
+	fmtc.NewLine()
+
+	for {
+		answer, err := input.Read(
+			"What to do? [s]kip, [o]verwrite, [b]ackup and overwrite, [a]bort",
+			input.NotEmpty,
+		)
+
+		if err != nil {
+			return "", err
+		}
+
+		switch strings.ToLower(answer) {
+		case "s", "skip":
+			return ACTION_SKIP, nil
+		case "o", "overwrite":
+			return ACTION_OVERWRITE, nil
+		case "b", "backup":
+			return ACTION_BACKUP, nil
+		case "a", "abort":
+			return "", fmt.Errorf("Generation aborted by user")
+		}
+
+		terminal.Warn("Unknown answer %q\n", answer)
+	}
+}
+
+// printDiff prints unified diff with colors
+func printDiff(diff string) {
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			fmtc.Printfn("{*}%s{!}", line)
+		case strings.HasPrefix(line, "@@"):
+			fmtc.Printfn("{c}%s{!}", line)
+		case strings.HasPrefix(line, "+"):
+			fmtc.Printfn("{g}%s{!}", line)
+		case strings.HasPrefix(line, "-"):
+			fmtc.Printfn("{r}%s{!}", line)
+		case line != "":
+			fmtc.Printfn("{s}%s{!}", line)
+		}
+	}
+}
+
+// printGenerationReport prints info about created, skipped, overwritten and
+// backed-up files
+func printGenerationReport(report *GenerationReport) {
+	if report.IsEmpty() {
+		return
+	}
+
+	printReportGroup("Created", "{g}", report.Created)
+	printReportGroup("Overwritten", "{y}", report.Overwritten)
+	printReportGroup("Backed up and overwritten", "{y}", report.BackedUp)
+	printReportGroup("Skipped", "{s}", report.Skipped)
+}
+
+// printReportGroup prints group of files from generation report
+func printReportGroup(name, colorTag string, files []string) {
+	if len(files) == 0 {
+		return
+	}
+
+	fmtc.Printfn("{*}%s{!} {s-}(%d){!}", name, len(files))
+
+	for _, file := range files {
+		fmtc.Printfn(" "+colorTag+"•{!} %s", file)
+	}
+
+	fmtc.NewLine()
+}
+
+// checkTargetDir checks target dir
+func checkTargetDir(dir string, allowNonEmpty bool) error {
+	if !fsutil.IsExist(dir) {
+		// Missing directories will be created in the nearest existing parent
+		return fsutil.ValidatePerms("DWX", path.Dir(getMissingDir(dir)))
+	}
+
+	err := fsutil.ValidatePerms("DRWX", dir)
+
+	if err != nil || allowNonEmpty {
+		return err
+	}
+
+	objects := fsutil.List(dir, false, fsutil.ListingFilter{
+		NotMatchPatterns: allowedTargetFiles,
+	})
+
+	if len(objects) != 0 {
+		return fmt.Errorf("Target directory is not empty")
+	}
+
+	return nil
+}
+
+// getMissingDir returns the topmost directory from given path which doesn't exist.
+// It returns empty string if directory exists.
+func getMissingDir(dir string) string {
+	if fsutil.IsExist(dir) {
+		return ""
+	}
+
+	for dir != "/" && !fsutil.IsExist(path.Dir(dir)) {
+		dir = path.Dir(dir)
+	}
+
+	return dir
+}
+
+// removeMissingDir removes directory which was created by scratch
+func removeMissingDir(dir string) {
+	if dir != "" {
+		os.RemoveAll(dir)
+	}
+}
+
+// formatTemplateLayer returns info about template layer and shadowed layers
+func formatTemplateLayer(t *Template) string {
+	if len(t.Shadows) == 0 {
+		return t.Layer.Name
+	}
+
+	var shadows []string
+
+	for _, layer := range t.Shadows {
+		shadows = append(shadows, layer.Name)
+	}
+
+	return t.Layer.Name + ", shadows " + strings.Join(shadows, ", ")
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// printCompletion prints completion for given shell
+func printCompletion() int {
+	info := genUsage()
+
+	switch options.GetS(OPT_COMPLETION) {
+	case "bash":
+		fmt.Print(bash.Generate(info, APP))
+	case "fish":
+		fmt.Print(fish.Generate(info, APP))
+	case "zsh":
+		fmt.Print(zsh.Generate(info, optMap, APP))
+	default:
+		return 1
+	}
+
+	return 0
+}
+
+// printMan prints man page
+func printMan() {
+	fmt.Println(man.Generate(genUsage(), genAbout("")))
+}
+
+// genUsage generates usage info
+func genUsage() *usage.Info {
+	info := usage.NewInfo("", "template", "target-dir")
+
+	info.AddOption(OPT_TEMPLATES_DIR, "Path to directory with templates", "dir")
+	info.AddOption(OPT_VAR, "Set variable value {s-}(can be used multiple times){!}", "name=value")
+	info.AddOption(OPT_ANSWERS, "Read variables values from JSON or YAML file", "file")
+	info.AddOption(OPT_YES, "Don't ask for confirmation")
+	info.AddOption(OPT_REPLAY, "Generate files using template and variables from project info file", "file")
+	info.AddOption(OPT_CONFLICT, "Action for existing files {s-}(skip/overwrite/backup/prompt/fail){!}", "action")
+	info.AddOption(OPT_NO_HOOKS, "Don't run template hooks")
+	info.AddOption(OPT_TRUST, "Trust template hooks without confirmation")
+	info.AddOption(OPT_FORMAT, "Output format of diff command {s-}(text/json){!}", "format")
+	info.AddOption(OPT_DRY_RUN, "Show files which will be generated without creating them")
+	info.AddOption(OPT_SHOW, "Print rendered file from template without creating files", "file")
+	info.AddOption(OPT_NO_COLOR, "Disable colors in output")
+	info.AddOption(OPT_HELP, "Show this help message")
+	info.AddOption(OPT_VER, "Show version")
+
+	info.AddEnv(ENV_TEMPLATES, "List of directories with templates separated by colon")
+	info.AddEnv("XDG_CONFIG_HOME", "Base directory for user templates {s-}(default: ~/.config){!}")
+
+	info.AddExample("package", "List files in template \"package\"")
+	info.AddExample(
+		"package .",
+		"Generate files based on template \"package\" in current directory",
+	)
+	info.AddExample(
 		"service $GOPATH/src/github.com/essentialkaos/myapp",
 		"Generate files based on template \"service\" in given directory",
 	)
+	info.AddExample(
+		"-y -V NAME=MyApp -V SHORT_NAME=myapp -A answers.yml service myapp",
+		"Generate files based on template \"service\" without prompts",
+	)
+	info.AddExample(
+		"--conflict prompt service .",
+		"Apply template \"service\" to existing project in current directory",
+	)
+	info.AddExample(
+		"update myapp",
+		"Update project in directory \"myapp\" to the latest revision of its template",
+	)
+	info.AddExample(
+		"diff myapp",
+		"Show differences between project in directory \"myapp\" and its template",
+	)
+	info.AddExample(
+		"add github/workflows myapp/.github/workflows",
+		"Generate files from directory \"workflows\" of template \"github\" in existing project",
+	)
+	info.AddExample(
+		"--replay myapp/.scratch.json myapp-copy",
+		"Generate files using template and variables from existing project",
+	)
+	info.AddExample(
+		"--dry-run service myapp",
+		"Show files which will be generated based on template \"service\"",
+	)
+	info.AddExample(
+		"-t ~/work/templates package .",
+		"Generate files based on template \"package\" from custom directory",
+	)
 
 	return info
 }
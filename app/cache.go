@@ -0,0 +1,348 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/jsonutil"
+	"github.com/essentialkaos/ek/v13/path"
+	"github.com/essentialkaos/ek/v13/system"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getCacheDir returns path to directory with snapshots of templates
+func getCacheDir() (string, error) {
+	cacheDir := os.Getenv("XDG_CACHE_HOME")
+
+	if cacheDir == "" {
+		user, err := system.CurrentUser()
+
+		if err != nil {
+			return "", fmt.Errorf("Can't get current user info: %w", err)
+		}
+
+		cacheDir = path.Join(user.HomeDir, ".cache")
+	}
+
+	return path.Join(cacheDir, "scratch/templates"), nil
+}
+
+// getSnapshotDir returns path to directory with snapshot of given template revision
+func getSnapshotDir(revision string) (string, error) {
+	cacheDir, err := getCacheDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	_, hash, ok := strings.Cut(revision, ":")
+
+	if !ok || hash == "" || strings.ContainsAny(hash, "/.") {
+		return "", fmt.Errorf("Invalid template revision %q", revision)
+	}
+
+	return path.Join(cacheDir, hash), nil
+}
+
+// saveTemplateSnapshot saves copy of template files to cache
+func saveTemplateSnapshot(tmpl *Template, revision string) error {
+	snapshotDir, err := getSnapshotDir(revision)
+
+	if err != nil || fsutil.IsExist(snapshotDir) {
+		return err
+	}
+
+	err = os.MkdirAll(path.Dir(snapshotDir), 0700)
+
+	if err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp(path.Dir(snapshotDir), ".snapshot-")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(tmpDir)
+
+	for _, file := range tmpl.Data {
+		err = copySnapshotObject(tmpl.SourcePath(file), tmpDir+"/"+tmpl.Name+"/"+file)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	// Partials from all sources are saved to template, because shared partials
+	// can be changed later
+	for _, name := range getPartialsNames(tmpl) {
+		err = copySnapshotObject(
+			tmpl.PartialPath(name),
+			tmpDir+"/"+tmpl.Name+"/"+PARTIALS_DIR+"/"+name,
+		)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	err = saveSnapshotManifest(tmpl, tmpDir+"/"+tmpl.Name)
+
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpDir, snapshotDir)
+}
+
+// saveSnapshotManifest saves template manifest to snapshot. Manifest of template
+// with base templates is saved already merged, so snapshot doesn't depend on
+// current state of base templates.
+func saveSnapshotManifest(tmpl *Template, dir string) error {
+	switch {
+	case tmpl.Manifest == nil:
+		return nil
+	case len(tmpl.Bases) == 0:
+		return copySnapshotObject(tmpl.Path+"/"+tmpl.Manifest.File, dir+"/"+tmpl.Manifest.File)
+	}
+
+	manifest := *tmpl.Manifest
+	manifest.Extends = nil
+	manifest.Engines = tmpl.Engines
+
+	// JSON is a valid YAML, so we can keep the original file name
+	return jsonutil.Write(dir+"/"+tmpl.Manifest.File, &manifest, 0600)
+}
+
+// copySnapshotObject copies file, symlink or empty directory to snapshot
+func copySnapshotObject(source, target string) error {
+	info, err := os.Lstat(source)
+
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(path.Dir(target), 0755)
+
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.IsDir():
+		return os.Mkdir(target, info.Mode().Perm())
+
+	case info.Mode()&os.ModeSymlink != 0:
+		link, err := os.Readlink(source)
+
+		if err != nil {
+			return err
+		}
+
+		return os.Symlink(link, target)
+	}
+
+	data, err := os.ReadFile(source)
+
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(target, data, 0600)
+
+	if err != nil {
+		return err
+	}
+
+	return os.Chmod(target, info.Mode().Perm())
+}
+
+// getTemplateRevisionData returns template with given revision from cache or
+// git repository. Returned function removes temporary data.
+func getTemplateRevisionData(tmpl *Template, project *ProjectInfo) (*Template, func(), error) {
+	snapshotDir, err := getSnapshotDir(project.Revision)
+
+	if err == nil && fsutil.IsDir(snapshotDir+"/"+tmpl.Name) {
+		snapshot, err := readTemplate(&TemplatesLayer{Name: "cache", Path: snapshotDir}, tmpl.Name)
+		return snapshot, func() {}, err
+	}
+
+	if project.GitRevision == "" {
+		return nil, nil, fmt.Errorf(
+			"Can't find template revision %s in cache and project info doesn't contain git revision",
+			project.Revision,
+		)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "scratch-")
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cleanup := func() { os.RemoveAll(tmpDir) }
+
+	err = extractGitRevision(tmpl.Path, project.GitRevision, tmpDir+"/"+tmpl.Name)
+
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("Can't read template revision %s from git: %w", project.GitRevision, err)
+	}
+
+	snapshot, err := readTemplate(&TemplatesLayer{Name: "git", Path: tmpDir}, tmpl.Name)
+
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	// Template could have uncommitted changes during project generation, so
+	// data from git can differ from the data used for generation
+	revision, err := getTemplateRevision(snapshot)
+
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	if revision != project.Revision {
+		cleanup()
+		return nil, nil, fmt.Errorf(
+			"Template from git revision %s doesn't match template revision %s used for project generation",
+			project.GitRevision, project.Revision,
+		)
+	}
+
+	return snapshot, cleanup, nil
+}
+
+// extractGitRevision extracts directory with given revision from git repository
+func extractGitRevision(dir, revision, targetDir string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), GIT_TIMEOUT)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--show-toplevel", "--show-prefix")
+	cmd.Dir = dir
+
+	output, err := cmd.Output()
+
+	if err != nil {
+		return err
+	}
+
+	// Prefix is empty if template is stored in the root of repository
+	repoDir, prefix, _ := strings.Cut(strings.TrimRight(string(output), "\n"), "\n")
+	treeish := revision + ":" + strings.TrimSuffix(prefix, "/")
+
+	var stderr bytes.Buffer
+
+	// Archive must be created from the root of repository, otherwise git
+	// limits it to the current directory. Umask is the same as git uses for
+	// checkout with default umask, so modes of files match modes in working tree.
+	cmd = exec.CommandContext(ctx, "git", "-c", "tar.umask=0022", "archive", "--format=tar", treeish)
+	cmd.Dir = repoDir
+	cmd.Stderr = &stderr
+
+	data, err := cmd.Output()
+
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return extractTar(bytes.NewReader(data), targetDir)
+}
+
+// extractTar extracts tar archive to given directory
+func extractTar(r io.Reader, targetDir string) error {
+	tr := tar.NewReader(r)
+
+	err := os.MkdirAll(targetDir, 0755)
+
+	if err != nil {
+		return err
+	}
+
+	for {
+		hdr, err := tr.Next()
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		name := path.Clean(hdr.Name)
+
+		if name == "." {
+			continue
+		}
+
+		if validateTargetPath(name) != nil {
+			return fmt.Errorf("Archive contains invalid path %q", hdr.Name)
+		}
+
+		target := targetDir + "/" + name
+		err = os.MkdirAll(path.Dir(target), 0755)
+
+		if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0755)
+
+		case tar.TypeSymlink:
+			err = os.Symlink(hdr.Linkname, target)
+
+		case tar.TypeReg:
+			err = writeTarFile(tr, target, os.FileMode(hdr.Mode).Perm())
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// writeTarFile writes file from tar archive
+func writeTarFile(r io.Reader, target string, mode os.FileMode) error {
+	fd, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(fd, r)
+
+	if err != nil {
+		fd.Close()
+		return err
+	}
+
+	err = fd.Close()
+
+	if err != nil {
+		return err
+	}
+
+	return os.Chmod(target, mode)
+}
@@ -0,0 +1,100 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/path"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ComponentPath returns path of given template file relative to component
+// directory
+func (t *Template) ComponentPath(file string) string {
+	if t.Root == "" {
+		return file
+	}
+
+	return strings.TrimPrefix(file, t.Root+"/")
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// selectComponent limits template data to files from given subdirectory and
+// removes actions and hooks, because they can change files of the whole project
+func selectComponent(tmpl *Template, subDir string) error {
+	tmpl.IsComponent = true
+
+	if subDir != "" {
+		subDir = path.Clean(subDir)
+
+		if validateTargetPath(subDir) != nil || isPartialsFile(subDir) {
+			return fmt.Errorf("Invalid template directory %q", subDir)
+		}
+
+		tmpl.Root = subDir
+		tmpl.Data = slices.DeleteFunc(tmpl.Data, func(file string) bool {
+			return !strings.HasPrefix(file, subDir+"/")
+		})
+
+		if len(tmpl.Data) == 0 {
+			return fmt.Errorf("Template %q has no directory %q", tmpl.Name, subDir)
+		}
+	}
+
+	if tmpl.Manifest != nil {
+		manifest := *tmpl.Manifest
+		manifest.Actions, manifest.Hooks = nil, ManifestHooks{}
+		tmpl.Manifest = &manifest
+	}
+
+	var err error
+
+	// Component can use only part of template variables
+	tmpl.Vars, err = extractVariables(tmpl)
+
+	return err
+}
+
+// findProject looks for root directory of project which contains given directory.
+// Root is a directory with project info file or git repository. If there is no
+// such directory, given directory is used as a root.
+func findProject(dir string) (*ProjectInfo, string, error) {
+	for root := dir; ; root = path.Dir(root) {
+		switch {
+		case fsutil.IsExist(root + "/" + PROJECT_FILE):
+			project, err := readProjectInfo(root + "/" + PROJECT_FILE)
+			return project, root, err
+		case fsutil.IsExist(root + "/.git"):
+			return nil, root, nil
+		case root == "/" || root == ".":
+			return nil, dir, nil
+		}
+	}
+}
+
+// checkComponentObjects checks that objects will be created inside component
+// directory and no symlinks from existing project are used in their paths
+func checkComponentObjects(objects []*TemplateObject, targetDir string) error {
+	for _, obj := range objects {
+		for dir := path.Dir(obj.Target); dir != "."; dir = path.Dir(dir) {
+			if fsutil.IsLink(targetDir + "/" + dir) {
+				return fmt.Errorf(
+					"Can't generate file %s: %s is a symbolic link", obj.Target, dir,
+				)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	CONFLICT_SKIP      = "skip"
+	CONFLICT_OVERWRITE = "overwrite"
+	CONFLICT_BACKUP    = "backup"
+	CONFLICT_PROMPT    = "prompt"
+	CONFLICT_FAIL      = "fail"
+)
+
+const (
+	ACTION_CREATE    = "create"
+	ACTION_SKIP      = CONFLICT_SKIP
+	ACTION_OVERWRITE = CONFLICT_OVERWRITE
+	ACTION_BACKUP    = CONFLICT_BACKUP
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Conflict contains info about template object which already exists in target
+// directory
+type Conflict struct {
+	Object   *TemplateObject // Template object
+	File     string          // Path to existing file
+	Rendered []byte          // Rendered file data (only for regular files)
+}
+
+// ConflictHandler returns action for given conflict
+type ConflictHandler func(c *Conflict) (string, error)
+
+// GenerationReport contains info about generated files
+type GenerationReport struct {
+	Created     []string
+	Skipped     []string
+	Overwritten []string
+	BackedUp    []string
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// conflictStrategies is list of supported conflict strategies
+var conflictStrategies = []string{
+	CONFLICT_SKIP, CONFLICT_OVERWRITE, CONFLICT_BACKUP, CONFLICT_PROMPT, CONFLICT_FAIL,
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// IsEmpty returns true if report doesn't contain info about any file
+func (r *GenerationReport) IsEmpty() bool {
+	return r == nil || len(r.Created)+len(r.Skipped)+len(r.Overwritten)+len(r.BackedUp) == 0
+}
+
+// Generated returns list of all files written to target directory
+func (r *GenerationReport) Generated() []string {
+	return slices.Concat(r.Created, r.Overwritten, r.BackedUp)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// isValidConflictStrategy returns true if given conflict strategy is supported
+func isValidConflictStrategy(strategy string) bool {
+	return slices.Contains(conflictStrategies, strategy)
+}
+
+// checkConflictStrategy returns error if given conflict strategy is not supported
+func checkConflictStrategy(strategy string) error {
+	if strategy == "" || isValidConflictStrategy(strategy) {
+		return nil
+	}
+
+	return fmt.Errorf(
+		"Unsupported conflict strategy %q (supported: %s)",
+		strategy, strings.Join(conflictStrategies, ", "),
+	)
+}
+
+// resolveConflicts returns actions for all template objects. Handler is called for
+// every object which already exists and differs from rendered one. If handler is
+// nil, any conflict is an error.
+func resolveConflicts(tmpl *Template, objects []*TemplateObject, targetDir string, handler ConflictHandler) (map[*TemplateObject]string, error) {
+	result := make(map[*TemplateObject]string, len(objects))
+
+	for _, obj := range objects {
+		targetFile := targetDir + "/" + obj.Target
+		info, err := os.Lstat(targetFile)
+
+		if err != nil {
+			result[obj] = ACTION_CREATE
+			continue
+		}
+
+		conflict := &Conflict{Object: obj, File: targetFile}
+
+		switch {
+		case obj.IsDir():
+			if info.IsDir() {
+				result[obj] = ACTION_SKIP
+				continue
+			}
+
+		case obj.IsLink():
+			link, _ := os.Readlink(targetFile)
+
+			if info.Mode()&os.ModeSymlink != 0 && link == obj.Link {
+				result[obj] = ACTION_SKIP
+				continue
+			}
+
+		default:
+			rendered, _, err := renderTemplateFileToBuffer(tmpl, obj)
+
+			if err != nil {
+				return nil, fmt.Errorf("Can't render file %s: %w", obj.Name, err)
+			}
+
+			if info.Mode().IsRegular() {
+				current, err := os.ReadFile(targetFile)
+
+				if err == nil && bytes.Equal(current, rendered) {
+					result[obj] = ACTION_SKIP
+					continue
+				}
+			}
+
+			conflict.Rendered = rendered
+		}
+
+		if handler == nil {
+			return nil, fmt.Errorf("File %s already exists", obj.Target)
+		}
+
+		action, err := handler(conflict)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if action == ACTION_OVERWRITE && info.IsDir() && !obj.IsDir() {
+			return nil, fmt.Errorf("Can't overwrite directory %s with file", obj.Target)
+		}
+
+		result[obj] = action
+	}
+
+	return result, nil
+}
+
+// backupFile renames given file to the first free backup name and returns this name
+func backupFile(file string) (string, error) {
+	backup := file + ".bak"
+
+	for i := 1; fsutil.IsExist(backup) || fsutil.IsLink(backup); i++ {
+		backup = fmt.Sprintf("%s.bak.%d", file, i)
+	}
+
+	return backup, os.Rename(file, backup)
+}
@@ -0,0 +1,147 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// IsDerived returns true if variable value is calculated from other variables
+func (vi VariableInfo) IsDerived() bool {
+	return vi.Derive != ""
+}
+
+// GetDefault returns default value with applied values of other variables. If
+// default value references variable without value, it returns empty string. Value
+// inferred from target directory takes precedence over default value.
+func (vi VariableInfo) GetDefault(vars Variables) string {
+	switch {
+	case vi.Inferred != "":
+		return vi.Inferred
+	case vi.Default == "":
+		return ""
+	}
+
+	for _, dep := range getReferencedVariables(vi.Default) {
+		if vars[dep] == "" {
+			return ""
+		}
+	}
+
+	return vi.Canonical(applyVariables(vi.Default, vars))
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getReferencedVariables returns names of all variables used in given string
+func getReferencedVariables(data string) []string {
+	var result []string
+
+	for _, fns := range varRegex.FindAllStringSubmatch(data, -1) {
+		result = append(result, fns[1])
+	}
+
+	return result
+}
+
+// addDerivedDependencies adds all variables required for calculating values of
+// derived variables and dynamic variables used in default values
+func addDerivedDependencies(vars Variables, info *VariableInfoStore) {
+	for changed := true; changed; {
+		changed = false
+
+		for v := range vars {
+			for _, dep := range getReferencedVariables(info.Info[v].Derive) {
+				if !vars.Has(dep) {
+					vars[dep] = ""
+					changed = true
+				}
+			}
+
+			for _, dep := range getReferencedVariables(info.Info[v].Default) {
+				depInfo := info.Info[dep]
+
+				if !vars.Has(dep) && depInfo.IsDynamic && !depInfo.IsDerived() {
+					vars[dep] = ""
+					changed = true
+				}
+			}
+		}
+	}
+}
+
+// applyDerivedVariables calculates values of all derived variables
+func applyDerivedVariables(vars Variables, info *VariableInfoStore) {
+	done := make(map[string]bool)
+
+	for v := range vars {
+		deriveVariable(v, vars, info, done)
+	}
+}
+
+// deriveVariable calculates value of derived variable and all its dependencies
+func deriveVariable(name string, vars Variables, info *VariableInfoStore, done map[string]bool) {
+	varInfo := info.Info[name]
+
+	if done[name] || !varInfo.IsDerived() {
+		return
+	}
+
+	// Mark variable before processing dependencies to prevent infinite recursion
+	done[name] = true
+
+	for _, dep := range getReferencedVariables(varInfo.Derive) {
+		deriveVariable(dep, vars, info, done)
+	}
+
+	vars[name] = varInfo.Canonical(applyVariables(varInfo.Derive, vars))
+}
+
+// checkDerivedVariables checks that derived variables don't depend on themselves
+func checkDerivedVariables(info *VariableInfoStore) error {
+	state := make(map[string]uint8)
+
+	for name := range info.Info {
+		err := checkDerivedVariable(name, info, state, nil)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkDerivedVariable checks dependencies of derived variable for cycles
+func checkDerivedVariable(name string, info *VariableInfoStore, state map[string]uint8, chain []string) error {
+	chain = append(chain, name)
+
+	switch state[name] {
+	case 1:
+		return fmt.Errorf("Derived variables have cyclic dependency: %s", strings.Join(chain, " → "))
+	case 2:
+		return nil
+	}
+
+	state[name] = 1
+
+	for _, dep := range getReferencedVariables(info.Info[name].Derive) {
+		err := checkDerivedVariable(dep, info, state, chain)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	state[name] = 2
+
+	return nil
+}
@@ -0,0 +1,279 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	DIFF_EQUAL  byte = ' '
+	DIFF_DELETE byte = '-'
+	DIFF_INSERT byte = '+'
+)
+
+// DIFF_CONTEXT is number of context lines in unified diff
+const DIFF_CONTEXT = 3
+
+// DIFF_MAX_EDITS is maximum number of edits for searching the shortest edit script.
+// If files have more differences, diff is generated as full replacement.
+const DIFF_MAX_EDITS = 2000
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// diffOp is single operation of edit script
+type diffOp struct {
+	Kind byte   // Operation type (DIFF_EQUAL, DIFF_DELETE or DIFF_INSERT)
+	Line string // Line with line ending
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// splitLines splits data into lines keeping line endings
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// diffLines returns edit script for transforming a into b using Myers algorithm
+func diffLines(a, b []string) []diffOp {
+	var prefix, suffix []diffOp
+
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		prefix = append(prefix, diffOp{DIFF_EQUAL, a[0]})
+		a, b = a[1:], b[1:]
+	}
+
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		suffix = append(suffix, diffOp{DIFF_EQUAL, a[len(a)-1]})
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+
+	result := append(prefix, diffMyers(a, b)...)
+
+	for i := len(suffix) - 1; i >= 0; i-- {
+		result = append(result, suffix[i])
+	}
+
+	return result
+}
+
+// diffMyers finds the shortest edit script for transforming a into b
+func diffMyers(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	total := n + m
+
+	if total == 0 {
+		return nil
+	}
+
+	offset := total + 1
+	v := make([]int, 2*total+3)
+
+	var trace [][]int
+
+SEARCH:
+	for d := 0; d <= total; d++ {
+		if d > DIFF_MAX_EDITS {
+			return diffReplace(a, b)
+		}
+
+		// Keep only part of V which can be used on this step for backtracking
+		trace = append(trace, append([]int{}, v[offset-d-1:offset+d+2]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+
+			y := x - k
+
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				break SEARCH
+			}
+		}
+	}
+
+	var result []diffOp
+
+	x, y := n, m
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		v, base := trace[d], d+1
+		k := x - y
+
+		var prevK int
+
+		if k == -d || (k != d && v[base+k-1] < v[base+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := v[base+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			result = append(result, diffOp{DIFF_EQUAL, a[x-1]})
+			x, y = x-1, y-1
+		}
+
+		if d > 0 {
+			if x == prevX {
+				result = append(result, diffOp{DIFF_INSERT, b[y-1]})
+				y--
+			} else {
+				result = append(result, diffOp{DIFF_DELETE, a[x-1]})
+				x--
+			}
+		}
+	}
+
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+
+	return result
+}
+
+// diffReplace returns edit script which replaces all lines from a with lines from b
+func diffReplace(a, b []string) []diffOp {
+	var result []diffOp
+
+	for _, line := range a {
+		result = append(result, diffOp{DIFF_DELETE, line})
+	}
+
+	for _, line := range b {
+		result = append(result, diffOp{DIFF_INSERT, line})
+	}
+
+	return result
+}
+
+// unifiedDiff returns unified diff between two versions of file. If data is equal
+// it returns empty string.
+func unifiedDiff(a, b []byte, nameA, nameB string) string {
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var buf strings.Builder
+
+	for start := 0; start < len(ops); {
+		// Find the next changed line
+		for start < len(ops) && ops[start].Kind == DIFF_EQUAL {
+			start++
+		}
+
+		if start == len(ops) {
+			break
+		}
+
+		hunkStart := max(start-DIFF_CONTEXT, 0)
+		hunkEnd := start
+
+		// Extend hunk while changes are separated by less than 2 contexts
+		for i, equal := start, 0; i < len(ops); i++ {
+			if ops[i].Kind != DIFF_EQUAL {
+				equal, hunkEnd = 0, i+1
+				continue
+			}
+
+			equal++
+
+			if equal > DIFF_CONTEXT*2 {
+				break
+			}
+		}
+
+		hunkEnd = min(hunkEnd+DIFF_CONTEXT, len(ops))
+
+		if buf.Len() == 0 {
+			fmt.Fprintf(&buf, "--- %s\n+++ %s\n", nameA, nameB)
+		}
+
+		writeDiffHunk(&buf, ops, hunkStart, hunkEnd)
+
+		start = hunkEnd
+	}
+
+	return buf.String()
+}
+
+// writeDiffHunk writes hunk with given operations to buffer
+func writeDiffHunk(buf *strings.Builder, ops []diffOp, start, end int) {
+	var lineA, lineB, sizeA, sizeB int
+
+	for _, op := range ops[:start] {
+		if op.Kind != DIFF_INSERT {
+			lineA++
+		}
+
+		if op.Kind != DIFF_DELETE {
+			lineB++
+		}
+	}
+
+	for _, op := range ops[start:end] {
+		if op.Kind != DIFF_INSERT {
+			sizeA++
+		}
+
+		if op.Kind != DIFF_DELETE {
+			sizeB++
+		}
+	}
+
+	fmt.Fprintf(
+		buf, "@@ -%s +%s @@\n",
+		formatHunkRange(lineA, sizeA), formatHunkRange(lineB, sizeB),
+	)
+
+	for _, op := range ops[start:end] {
+		buf.WriteByte(op.Kind)
+		buf.WriteString(op.Line)
+
+		if !strings.HasSuffix(op.Line, "\n") {
+			buf.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// formatHunkRange formats range of lines for hunk header
+func formatHunkRange(line, size int) string {
+	switch size {
+	case 0:
+		return fmt.Sprintf("%d,0", line)
+	case 1:
+		return fmt.Sprintf("%d", line+1)
+	}
+
+	return fmt.Sprintf("%d,%d", line+1, size)
+}
@@ -0,0 +1,256 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/path"
+	"github.com/essentialkaos/ek/v13/sortutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	DRIFT_MISSING   = "missing"
+	DRIFT_ADDED     = "added"
+	DRIFT_MODIFIED  = "modified"
+	DRIFT_IDENTICAL = "identical"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// DriftReport contains info about differences between project and its template
+type DriftReport struct {
+	Template         string        `json:"template"`          // Template name
+	Revision         string        `json:"revision"`          // Template revision used for generation
+	TemplateRevision string        `json:"template_revision"` // Current template revision
+	Summary          DriftSummary  `json:"summary"`           // Number of files with each status
+	Files            []*DriftEntry `json:"files"`             // Info about all files
+}
+
+// DriftSummary contains number of files with each status
+type DriftSummary struct {
+	Missing   int `json:"missing"`
+	Added     int `json:"added"`
+	Modified  int `json:"modified"`
+	Identical int `json:"identical"`
+}
+
+// DriftEntry contains info about single file of project
+type DriftEntry struct {
+	Path   string `json:"path"`             // Path relative to project directory (directories end with slash)
+	Status string `json:"status"`           // File status
+	Reason string `json:"reason,omitempty"` // Reason of modification (for non-text files)
+	Diff   string `json:"diff,omitempty"`   // Unified diff (for modified text files)
+}
+
+// DriftNode is node of project files tree
+type DriftNode struct {
+	Name     string
+	Entry    *DriftEntry
+	Children []*DriftNode
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// HasChanges returns true if project differs from template
+func (r *DriftReport) HasChanges() bool {
+	return r.Summary.Missing+r.Summary.Added+r.Summary.Modified != 0
+}
+
+// Child returns child node with given name, node is created if it doesn't exist
+func (n *DriftNode) Child(name string) *DriftNode {
+	for _, child := range n.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+
+	child := &DriftNode{Name: name}
+	n.Children = append(n.Children, child)
+
+	return child
+}
+
+// IsDir returns true if node is a directory
+func (n *DriftNode) IsDir() bool {
+	return n.Entry == nil || len(n.Children) != 0 || strings.HasSuffix(n.Entry.Path, "/")
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getDriftReport compares rendered template objects with project directory
+func getDriftReport(objects map[string]*RenderedObject, dir string) (*DriftReport, error) {
+	report := &DriftReport{}
+
+	for target, obj := range objects {
+		entry := getDriftEntry(obj, dir+"/"+target)
+		entry.Path = target
+
+		if obj.Object.IsDir() {
+			entry.Path += "/"
+		}
+
+		report.Files = append(report.Files, entry)
+	}
+
+	added, err := findAddedFiles(objects, dir, "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range added {
+		report.Files = append(report.Files, &DriftEntry{Path: file, Status: DRIFT_ADDED})
+	}
+
+	sortDriftEntries(report.Files)
+
+	for _, entry := range report.Files {
+		switch entry.Status {
+		case DRIFT_MISSING:
+			report.Summary.Missing++
+		case DRIFT_ADDED:
+			report.Summary.Added++
+		case DRIFT_MODIFIED:
+			report.Summary.Modified++
+		case DRIFT_IDENTICAL:
+			report.Summary.Identical++
+		}
+	}
+
+	return report, nil
+}
+
+// getDriftEntry compares rendered object with file in project
+func getDriftEntry(obj *RenderedObject, file string) *DriftEntry {
+	info, err := os.Lstat(file)
+
+	switch {
+	case err != nil:
+		return &DriftEntry{Status: DRIFT_MISSING}
+
+	case isSameObject(obj, file, info):
+		if !obj.Object.IsDir() && !obj.Object.IsLink() &&
+			info.Mode().Perm() != obj.Object.Mode.Perm() {
+			return &DriftEntry{
+				Status: DRIFT_MODIFIED,
+				Reason: fmt.Sprintf("mode %04o → %04o", obj.Object.Mode.Perm(), info.Mode().Perm()),
+			}
+		}
+
+		return &DriftEntry{Status: DRIFT_IDENTICAL}
+
+	case obj.Object.IsLink() && info.Mode()&os.ModeSymlink != 0:
+		link, _ := os.Readlink(file)
+		return &DriftEntry{
+			Status: DRIFT_MODIFIED,
+			Reason: fmt.Sprintf("link target %s → %s", obj.Object.Link, link),
+		}
+
+	case obj.Object.IsDir(), obj.Object.IsLink(), !info.Mode().IsRegular():
+		return &DriftEntry{Status: DRIFT_MODIFIED, Reason: "type changed"}
+	}
+
+	data, err := os.ReadFile(file)
+
+	switch {
+	case err != nil:
+		return &DriftEntry{Status: DRIFT_MODIFIED, Reason: err.Error()}
+	case isBinaryBytes(data) || isBinaryBytes(obj.Data):
+		return &DriftEntry{Status: DRIFT_MODIFIED, Reason: "binary files differ"}
+	}
+
+	return &DriftEntry{
+		Status: DRIFT_MODIFIED,
+		Diff: unifiedDiff(
+			obj.Data, data,
+			"template/"+obj.Object.Target, "project/"+obj.Object.Target,
+		),
+	}
+}
+
+// findAddedFiles returns list of files in project which are not present in
+// template. Directories without template objects are reported as a whole.
+func findAddedFiles(objects map[string]*RenderedObject, dir, subDir string) ([]string, error) {
+	entries, err := os.ReadDir(path.Join(dir, subDir))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+
+	for _, entry := range entries {
+		file := path.Join(subDir, entry.Name())
+
+		obj := objects[file]
+
+		switch {
+		case file == PROJECT_FILE, file == ".git":
+			continue
+		case obj != nil && !obj.Object.IsDir():
+			// Object already compared with template
+			continue
+		case !entry.IsDir():
+			result = append(result, file)
+			continue
+		}
+
+		if !hasObjectsInDir(objects, file) {
+			if obj == nil {
+				result = append(result, file+"/")
+			}
+
+			continue
+		}
+
+		files, err := findAddedFiles(objects, dir, file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, files...)
+	}
+
+	return result, nil
+}
+
+// hasObjectsInDir returns true if there are template objects inside given
+// directory
+func hasObjectsInDir(objects map[string]*RenderedObject, dir string) bool {
+	for target := range objects {
+		if strings.HasPrefix(target, dir+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortDriftEntries sorts entries by path
+func sortDriftEntries(entries []*DriftEntry) {
+	var paths []string
+
+	index := make(map[string]*DriftEntry, len(entries))
+
+	for _, entry := range entries {
+		paths = append(paths, entry.Path)
+		index[entry.Path] = entry
+	}
+
+	sortutil.StringsNatural(paths)
+
+	for i, p := range paths {
+		entries[i] = index[p]
+	}
+}
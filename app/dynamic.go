@@ -0,0 +1,157 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/path"
+	"github.com/essentialkaos/ek/v13/system"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// GO_ENV_TIMEOUT is maximum duration of "go env" execution
+const GO_ENV_TIMEOUT = 5 * time.Second
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// GitConfig contains values from git config in "section.subsection.key" format
+type GitConfig map[string]string
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getGitIdentity returns user name and email from global git config files
+func getGitIdentity() (string, string) {
+	name, email := os.Getenv("GIT_AUTHOR_NAME"), os.Getenv("GIT_AUTHOR_EMAIL")
+
+	if name != "" && email != "" {
+		return name, email
+	}
+
+	var files []string
+
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	user, err := system.CurrentUser()
+
+	if err == nil {
+		if configDir == "" {
+			configDir = path.Join(user.HomeDir, ".config")
+		}
+
+		files = append(files, path.Join(configDir, "git/config"), path.Join(user.HomeDir, ".gitconfig"))
+	} else if configDir != "" {
+		files = append(files, path.Join(configDir, "git/config"))
+	}
+
+	// Values from ~/.gitconfig take precedence over values from XDG config
+	for _, file := range files {
+		config, err := readGitConfig(file)
+
+		if err != nil {
+			continue
+		}
+
+		if config["user.name"] != "" && os.Getenv("GIT_AUTHOR_NAME") == "" {
+			name = config["user.name"]
+		}
+
+		if config["user.email"] != "" && os.Getenv("GIT_AUTHOR_EMAIL") == "" {
+			email = config["user.email"]
+		}
+	}
+
+	return name, email
+}
+
+// readGitConfig reads git config file
+func readGitConfig(file string) (GitConfig, error) {
+	data, err := os.ReadFile(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var section string
+
+	result := make(GitConfig)
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' {
+			header, _, ok := strings.Cut(line[1:], "]")
+
+			if !ok {
+				continue
+			}
+
+			name, sub, hasSub := strings.Cut(header, " ")
+			section = strings.ToLower(strings.TrimSpace(name))
+
+			if hasSub {
+				section += "." + strings.Trim(strings.TrimSpace(sub), `"`)
+			}
+
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+
+		if !ok {
+			value = "true"
+		}
+
+		key = strings.ToLower(strings.TrimSpace(key))
+		result[section+"."+key] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+
+	return result, nil
+}
+
+// getGoVersion returns version of installed Go toolchain or version used for
+// building scratch
+func getGoVersion() string {
+	ctx, cancel := context.WithTimeout(context.Background(), GO_ENV_TIMEOUT)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "go", "env", "GOVERSION").Output()
+	version := strings.TrimSpace(string(output))
+
+	if err != nil || !strings.HasPrefix(version, "go") {
+		version = runtime.Version()
+	}
+
+	return strings.TrimPrefix(version, "go")
+}
+
+// getUserName returns name of current user
+func getUserName() string {
+	user, err := system.CurrentUser()
+
+	if err != nil {
+		return os.Getenv("USER")
+	}
+
+	return user.Name
+}
+
+// getHostname returns host name
+func getHostname() string {
+	hostname, _ := os.Hostname()
+	return hostname
+}
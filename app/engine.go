@@ -0,0 +1,150 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"io"
+	"text/template"
+	"text/template/parse"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	ENGINE_SIMPLE   = "simple"   // Only {{VAR}} substitution
+	ENGINE_TEMPLATE = "template" // Go text/template
+)
+
+// GO_TEMPLATE_SUFFIX is suffix of files which must be rendered using Go templates
+const GO_TEMPLATE_SUFFIX = ".tmpl"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// parseGoTemplate parses Go template with all supported functions
+func parseGoTemplate(name, data string) (*template.Template, error) {
+	return template.New(name).
+		Funcs(templateFuncs).
+		Option("missingkey=error").
+		Parse(data)
+}
+
+// executeGoTemplate renders Go template with given variables
+func executeGoTemplate(name, data string, vars Variables, info *VariableInfoStore, w io.Writer) error {
+	tmpl, err := parseGoTemplate(name, data)
+
+	if err != nil {
+		return err
+	}
+
+	return tmpl.Execute(w, getGoTemplateData(vars, info))
+}
+
+// getGoTemplateData converts variables to data for Go templates. Values of typed
+// variables are converted to bool, int or []string.
+func getGoTemplateData(vars Variables, info *VariableInfoStore) map[string]any {
+	result := make(map[string]any, len(vars))
+
+	for n, v := range vars {
+		result[n] = info.Info[n].TypedValue(v)
+	}
+
+	return result
+}
+
+// scanGoTemplateForVariables returns names of all variables used in Go template
+func scanGoTemplateForVariables(name, data string) ([]string, error) {
+	tmpl, err := parseGoTemplate(name, data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			result = walkGoTemplateNode(t.Tree.Root, result)
+		}
+	}
+
+	return result, nil
+}
+
+// walkGoTemplateNode walks over template tree and collects names of fields
+func walkGoTemplateNode(node parse.Node, result []string) []string {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return result
+		}
+
+		for _, nn := range n.Nodes {
+			result = walkGoTemplateNode(nn, result)
+		}
+
+	case *parse.ActionNode:
+		result = walkGoTemplateNode(n.Pipe, result)
+
+	case *parse.IfNode:
+		result = walkGoTemplateBranch(&n.BranchNode, result)
+
+	case *parse.RangeNode:
+		result = walkGoTemplateBranch(&n.BranchNode, result)
+
+	case *parse.WithNode:
+		result = walkGoTemplateBranch(&n.BranchNode, result)
+
+	case *parse.TemplateNode:
+		result = walkGoTemplateNode(n.Pipe, result)
+
+	case *parse.PipeNode:
+		if n == nil {
+			return result
+		}
+
+		for _, cmd := range n.Cmds {
+			result = walkGoTemplateNode(cmd, result)
+		}
+
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			result = walkGoTemplateNode(arg, result)
+		}
+
+	case *parse.ChainNode:
+		result = walkGoTemplateNode(n.Node, result)
+
+	case *parse.FieldNode:
+		result = appendGoTemplateField(n.Ident, result)
+
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			result = appendGoTemplateField(n.Ident[1:], result)
+		}
+	}
+
+	return result
+}
+
+// walkGoTemplateBranch walks over if/range/with node
+func walkGoTemplateBranch(n *parse.BranchNode, result []string) []string {
+	result = walkGoTemplateNode(n.Pipe, result)
+	result = walkGoTemplateNode(n.List, result)
+	result = walkGoTemplateNode(n.ElseList, result)
+
+	return result
+}
+
+// appendGoTemplateField appends name of variable from field identifier
+func appendGoTemplateField(ident []string, result []string) []string {
+	if len(ident) != 0 && varNameRegex.MatchString(ident[0]) {
+		result = append(result, ident[0])
+	}
+
+	return result
+}
@@ -0,0 +1,252 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/path"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// TemplateSource contains info about template which provides file
+type TemplateSource struct {
+	Name string // Name of template
+	Path string // Path to template directory
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// SourceDir returns path to directory of template which provides given file
+func (t *Template) SourceDir(file string) string {
+	source := t.Sources[file]
+
+	if source == nil {
+		return t.Path
+	}
+
+	return source.Path
+}
+
+// SourcePath returns path to given template file
+func (t *Template) SourcePath(file string) string {
+	return t.SourceDir(file) + "/" + file
+}
+
+// GetExtends returns names of base templates
+func (m *Manifest) GetExtends() []string {
+	if m == nil {
+		return nil
+	}
+
+	return m.Extends
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// readTemplateData reads files and manifest of template and merges them with
+// data from base templates. Chain contains directories of templates which extend
+// this template.
+func readTemplateData(layer *TemplatesLayer, templateName string, chain []string) (*Template, error) {
+	templateDir := layer.Path + "/" + templateName
+
+	if slices.Contains(chain, templateDir) {
+		var names []string
+
+		for _, dir := range chain {
+			names = append(names, path.Base(dir))
+		}
+
+		return nil, fmt.Errorf(
+			"Templates inheritance has cycle: %s → %s",
+			strings.Join(names, " → "), templateName,
+		)
+	}
+
+	manifest, err := readManifest(templateDir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := readTemplateObjects(templateDir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	files = slices.DeleteFunc(files, func(file string) bool {
+		return isPartialsFile(file) || (manifest != nil && file == manifest.File)
+	})
+
+	partials, err := readPartials(templateDir)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't read partials: %w", err)
+	}
+
+	tmpl := &Template{
+		Name:     templateName,
+		Path:     templateDir,
+		Layer:    layer,
+		Manifest: manifest,
+		Data:     files,
+		Partials: partials,
+	}
+
+	if manifest != nil {
+		// Snapshots contain engines of files from base templates
+		tmpl.Engines = manifest.Engines
+	}
+
+	if manifest == nil || len(manifest.Extends) == 0 {
+		return tmpl, nil
+	}
+
+	chain = append(chain, templateDir)
+
+	var bases []*Template
+
+	for _, baseName := range manifest.Extends {
+		baseLayer := findBaseTemplateLayer(baseName, templateName, layer)
+
+		switch {
+		case baseLayer == nil && baseName == templateName:
+			return nil, fmt.Errorf(
+				"Can't find base template %q in directories with lower priority", baseName,
+			)
+		case baseLayer == nil:
+			return nil, fmt.Errorf("Can't find base template %q", baseName)
+		}
+
+		base, err := readTemplateData(baseLayer, baseName, chain)
+
+		if err != nil {
+			return nil, err
+		}
+
+		bases = append(bases, base)
+	}
+
+	mergeBaseTemplates(tmpl, bases)
+
+	return tmpl, nil
+}
+
+// mergeBaseTemplates merges files and manifests of base templates into template.
+// Files and settings of the template override data from bases, later bases
+// override earlier ones.
+func mergeBaseTemplates(tmpl *Template, bases []*Template) {
+	var data []string
+	var manifest *Manifest
+
+	sources := make(map[string]*TemplateSource)
+	overrides := make(map[string]string)
+	partials := make(map[string]string)
+	engines := make(map[string]string)
+
+	for _, layer := range append(bases, tmpl) {
+		for _, file := range layer.Data {
+			if slices.Contains(data, file) {
+				overrides[file] = getFileSource(file, sources, tmpl).Name
+			} else {
+				data = append(data, file)
+			}
+
+			if layer == tmpl {
+				delete(sources, file)
+				delete(engines, file)
+				continue
+			}
+
+			engines[file] = layer.GetEngine(file)
+
+			source := layer.Sources[file]
+
+			if source == nil {
+				source = &TemplateSource{Name: layer.Name, Path: layer.Path}
+			}
+
+			sources[file] = source
+		}
+
+		for file, base := range layer.Overrides {
+			if overrides[file] == "" {
+				overrides[file] = base
+			}
+		}
+
+		maps.Copy(partials, layer.Partials)
+
+		manifest = mergeManifests(manifest, layer.Manifest)
+	}
+
+	slices.Sort(data)
+
+	tmpl.Bases = bases
+	tmpl.Data = data
+	tmpl.Sources = sources
+	tmpl.Overrides = overrides
+	tmpl.Engines = engines
+	tmpl.Manifest = manifest
+	tmpl.Partials = partials
+}
+
+// getFileSource returns source of file which is already merged
+func getFileSource(file string, sources map[string]*TemplateSource, tmpl *Template) *TemplateSource {
+	if sources[file] != nil {
+		return sources[file]
+	}
+
+	return &TemplateSource{Name: tmpl.Name, Path: tmpl.Path}
+}
+
+// mergeManifests merges child manifest into base manifest
+func mergeManifests(base, child *Manifest) *Manifest {
+	switch {
+	case base == nil:
+		return child
+	case child == nil:
+		result := *base
+		result.Engine = ""
+		return &result
+	}
+
+	// Engine of base template is used only for files of this template
+	result := &Manifest{
+		File:    child.File,
+		Engine:  child.Engine,
+		Extends: child.Extends,
+		Vars:    slices.Clone(base.Vars),
+		Raw:     slices.Concat(base.Raw, child.Raw),
+		Rules:   slices.Concat(base.Rules, child.Rules),
+		Actions: slices.Concat(base.Actions, child.Actions),
+		Hooks: ManifestHooks{
+			Pre:  slices.Concat(base.Hooks.Pre, child.Hooks.Pre),
+			Post: slices.Concat(base.Hooks.Post, child.Hooks.Post),
+		},
+	}
+
+	for _, v := range child.Vars {
+		index := slices.IndexFunc(result.Vars, func(bv *ManifestVariable) bool {
+			return bv.Name == v.Name
+		})
+
+		if index == -1 {
+			result.Vars = append(result.Vars, v)
+		} else {
+			result.Vars[index] = v
+		}
+	}
+
+	return result
+}
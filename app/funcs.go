@@ -0,0 +1,218 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/essentialkaos/ek/v13/timeutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// templateFuncs contains functions available in Go templates
+var templateFuncs = template.FuncMap{
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+	"title":   toTitleCase,
+	"snake":   toSnakeCase,
+	"camel":   toCamelCase,
+	"pascal":  toPascalCase,
+	"kebab":   toKebabCase,
+	"trim":    strings.TrimSpace,
+	"replace": strings.ReplaceAll,
+	"split":   splitList,
+	"join":    joinList,
+	"date":    formatDate,
+	"default": defaultValue,
+}
+
+// varFilters contains filters which can be applied to variables using pipe
+// syntax (e.g. {{NAME|snake}})
+var varFilters = map[string]func(string) string{
+	"upper":  strings.ToUpper,
+	"lower":  strings.ToLower,
+	"title":  toTitleCase,
+	"snake":  toSnakeCase,
+	"camel":  toCamelCase,
+	"pascal": toPascalCase,
+	"kebab":  toKebabCase,
+	"trim":   strings.TrimSpace,
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// applyFilters applies filters from pipe definition (e.g. "|snake|upper") to value
+func applyFilters(value, filters string) string {
+	for _, filter := range parseFilters(filters) {
+		fn, ok := varFilters[filter]
+
+		if ok {
+			value = fn(value)
+		}
+	}
+
+	return value
+}
+
+// validateFilters checks that all filters from pipe definition are supported
+func validateFilters(filters string) error {
+	for _, filter := range parseFilters(filters) {
+		_, ok := varFilters[filter]
+
+		if !ok {
+			return fmt.Errorf("Unknown filter %q", filter)
+		}
+	}
+
+	return nil
+}
+
+// parseFilters returns names of filters from pipe definition
+func parseFilters(filters string) []string {
+	if filters == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimPrefix(filters, "|"), "|")
+}
+
+// splitWords splits string into words using spaces, punctuation and case changes
+// as boundaries
+func splitWords(s string) []string {
+	var result []string
+	var word []rune
+
+	runes := []rune(s)
+
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) != 0 {
+				result, word = append(result, string(word)), nil
+			}
+
+			continue
+		}
+
+		// Split "myApp" → "my" + "App" and "HTTPServer" → "HTTP" + "Server"
+		if len(word) != 0 && unicode.IsUpper(r) {
+			prev := word[len(word)-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				(unicode.IsUpper(prev) && nextIsLower) {
+				result, word = append(result, string(word)), nil
+			}
+		}
+
+		word = append(word, r)
+	}
+
+	if len(word) != 0 {
+		result = append(result, string(word))
+	}
+
+	return result
+}
+
+// toTitleCase converts the first letter of every word to upper case
+func toTitleCase(s string) string {
+	result := []rune(s)
+
+	for i, r := range result {
+		if i == 0 || !unicode.IsLetter(result[i-1]) && !unicode.IsDigit(result[i-1]) {
+			result[i] = unicode.ToUpper(r)
+		}
+	}
+
+	return string(result)
+}
+
+// toSnakeCase converts string to snake_case
+func toSnakeCase(s string) string {
+	return strings.ToLower(strings.Join(splitWords(s), "_"))
+}
+
+// toKebabCase converts string to kebab-case
+func toKebabCase(s string) string {
+	return strings.ToLower(strings.Join(splitWords(s), "-"))
+}
+
+// toPascalCase converts string to PascalCase
+func toPascalCase(s string) string {
+	var buf strings.Builder
+
+	for _, word := range splitWords(s) {
+		buf.WriteString(toTitleCase(strings.ToLower(word)))
+	}
+
+	return buf.String()
+}
+
+// toCamelCase converts string to camelCase
+func toCamelCase(s string) string {
+	result := []rune(toPascalCase(s))
+
+	if len(result) != 0 {
+		result[0] = unicode.ToLower(result[0])
+	}
+
+	return string(result)
+}
+
+// splitList splits comma-separated list into slice
+func splitList(s string) []string {
+	var result []string
+
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+// joinList joins list items using given separator. Items can be a slice or a
+// comma-separated list.
+func joinList(sep string, items any) (string, error) {
+	switch t := items.(type) {
+	case []string:
+		return strings.Join(t, sep), nil
+	case string:
+		return strings.Join(splitList(t), sep), nil
+	}
+
+	return "", fmt.Errorf("join: unsupported list type %T", items)
+}
+
+// formatDate returns current date formatted using given layout (e.g. "%Y-%m-%d")
+func formatDate(layout string) string {
+	return timeutil.Format(time.Now(), layout)
+}
+
+// defaultValue returns default value if given value is empty
+func defaultValue(def string, value any) string {
+	if value == nil {
+		return def
+	}
+
+	result := fmt.Sprint(value)
+
+	if result == "" {
+		return def
+	}
+
+	return result
+}
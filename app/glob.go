@@ -0,0 +1,68 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// matchGlob returns true if path matches given glob pattern.
+//
+// Pattern without slashes matches file name in any directory, "*" matches any
+// sequence of characters except slash, "**" matches any number of directories
+// and "?" matches any single character except slash.
+func matchGlob(pattern, path string) bool {
+	re, err := compileGlob(pattern)
+
+	if err != nil {
+		return false
+	}
+
+	return re.MatchString(path)
+}
+
+// compileGlob converts glob pattern to regular expression
+func compileGlob(pattern string) (*regexp.Regexp, error) {
+	var buf strings.Builder
+
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+
+	buf.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+
+		switch c {
+		case '*':
+			if strings.HasPrefix(pattern[i:], "**/") {
+				buf.WriteString("(?:.*/)?")
+				i += 2
+			} else if strings.HasPrefix(pattern[i:], "**") {
+				buf.WriteString(".*")
+				i++
+			} else {
+				buf.WriteString("[^/]*")
+			}
+		case '?':
+			buf.WriteString("[^/]")
+		default:
+			buf.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+
+	buf.WriteString("$")
+
+	return regexp.Compile(buf.String())
+}
@@ -0,0 +1,287 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/pluralize"
+	"github.com/essentialkaos/ek/v13/timeutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	HOOK_PRE  = "pre"
+	HOOK_POST = "post"
+)
+
+// HOOK_TIMEOUT is default maximum duration of hook execution
+const HOOK_TIMEOUT = 5 * time.Minute
+
+// HOOK_WAIT_DELAY is maximum duration of waiting for hook output after hook exit
+// or timeout
+const HOOK_WAIT_DELAY = 3 * time.Second
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// hookStages is list of hook stages in execution order
+var hookStages = []string{HOOK_PRE, HOOK_POST}
+
+// hookStageNames contains human-readable names of hook stages
+var hookStageNames = map[string]string{
+	HOOK_PRE:  "Pre-generation",
+	HOOK_POST: "Post-generation",
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// hookOutput is writer which prints hook output line by line with hook name
+// as a prefix
+type hookOutput struct {
+	name string
+	buf  []byte
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// IsEmpty returns true if there are no hooks
+func (h ManifestHooks) IsEmpty() bool {
+	return len(h.Pre) == 0 && len(h.Post) == 0
+}
+
+// Get returns hooks for given stage
+func (h ManifestHooks) Get(stage string) []*ManifestHook {
+	switch stage {
+	case HOOK_PRE:
+		return h.Pre
+	case HOOK_POST:
+		return h.Post
+	}
+
+	return nil
+}
+
+// GetName returns hook name
+func (h *ManifestHook) GetName() string {
+	if h.Name != "" {
+		return h.Name
+	}
+
+	args, _ := splitCommand(h.Run)
+
+	if len(args) == 0 {
+		return "hook"
+	}
+
+	return args[0]
+}
+
+// GetTimeout returns maximum duration of hook execution
+func (h *ManifestHook) GetTimeout() time.Duration {
+	timeout, err := time.ParseDuration(h.Timeout)
+
+	if err != nil || timeout <= 0 {
+		return HOOK_TIMEOUT
+	}
+
+	return timeout
+}
+
+// Command returns hook command with variables values
+func (h *ManifestHook) Command(vars Variables) []string {
+	args, _ := splitCommand(h.Run)
+
+	for i, arg := range args {
+		args[i] = applyVariables(arg, vars)
+	}
+
+	return args
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// runHooks runs all hooks of given stage in target directory
+func runHooks(tmpl *Template, stage, dir string) error {
+	if tmpl.Manifest == nil {
+		return nil
+	}
+
+	hooks := tmpl.Manifest.Hooks.Get(stage)
+
+	for i, hook := range hooks {
+		args := hook.Command(tmpl.Vars)
+		start := time.Now()
+
+		fmtc.Printfn("{*}→ %s{!} {s-}(%s){!}", hook.GetName(), formatCommand(args))
+
+		err := runHook(hook, args, dir)
+
+		if err == nil {
+			fmtc.NewLine()
+			continue
+		}
+
+		fmtc.Printfn("\n{r}✖ Hook {*}%s{!*} failed{!}\n", hook.GetName())
+		fmtc.Printfn("  {s}Command:{!}  %s", formatCommand(args))
+		fmtc.Printfn("  {s}Error:{!}    %v", err)
+		fmtc.Printfn("  {s}Duration:{!} %s", timeutil.PrettyDuration(time.Since(start)))
+
+		if i+1 != len(hooks) {
+			fmtc.Printfn(
+				"  {s}Skipped:{!}  %s",
+				pluralize.P("%d %s", len(hooks)-i-1, "hook", "hooks"),
+			)
+		}
+
+		fmtc.NewLine()
+
+		return fmt.Errorf("%s hook %q failed", hookStageNames[stage], hook.GetName())
+	}
+
+	return nil
+}
+
+// runHook executes hook command and streams its output
+func runHook(hook *ManifestHook, args []string, dir string) error {
+	timeout := hook.GetTimeout()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	output := &hookOutput{name: hook.GetName()}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.WaitDelay = HOOK_WAIT_DELAY
+
+	err := cmd.Run()
+
+	output.Flush()
+
+	switch {
+	case err == nil, errors.Is(err, exec.ErrWaitDelay):
+		// Background processes started by hook can keep output open after
+		// the hook exits, it's not an error
+		return nil
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("Timeout (%s) exceeded", timeutil.PrettyDuration(timeout))
+	}
+
+	return err
+}
+
+// Write prints complete lines from hook output with hook name as a prefix
+func (o *hookOutput) Write(p []byte) (int, error) {
+	o.buf = append(o.buf, p...)
+
+	for {
+		index := bytes.IndexByte(o.buf, '\n')
+
+		if index == -1 {
+			break
+		}
+
+		o.printLine(o.buf[:index])
+		o.buf = o.buf[index+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush prints the last line of hook output without line break
+func (o *hookOutput) Flush() {
+	if len(o.buf) != 0 {
+		o.printLine(o.buf)
+		o.buf = nil
+	}
+}
+
+// printLine prints line of hook output with hook name as a prefix
+func (o *hookOutput) printLine(line []byte) {
+	fmtc.Printf("  {s-}%s │{!} ", o.name)
+	fmt.Fprintln(os.Stdout, string(bytes.TrimSuffix(line, []byte("\r"))))
+}
+
+// formatCommand returns command as a string with quoted arguments
+func formatCommand(args []string) string {
+	var result []string
+
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'\\") {
+			arg = fmt.Sprintf("%q", arg)
+		}
+
+		result = append(result, arg)
+	}
+
+	return strings.Join(result, " ")
+}
+
+// splitCommand splits command into arguments. Single and double quotes can be
+// used for arguments with spaces.
+func splitCommand(command string) ([]string, error) {
+	var result []string
+	var buf strings.Builder
+	var quote rune
+	var hasArg, escaped bool
+
+	for _, r := range command {
+		switch {
+		case escaped:
+			buf.WriteRune(r)
+			escaped = false
+
+		case r == '\\' && quote != '\'':
+			escaped, hasArg = true, true
+
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				buf.WriteRune(r)
+			}
+
+		case r == '"' || r == '\'':
+			quote, hasArg = r, true
+
+		case r == ' ' || r == '\t' || r == '\n':
+			if hasArg {
+				result = append(result, buf.String())
+				buf.Reset()
+				hasArg = false
+			}
+
+		default:
+			buf.WriteRune(r)
+			hasArg = true
+		}
+	}
+
+	switch {
+	case quote != 0:
+		return nil, fmt.Errorf("Command %q contains unclosed quote", command)
+	case escaped:
+		return nil, fmt.Errorf("Command %q ends with escape character", command)
+	}
+
+	if hasArg {
+		result = append(result, buf.String())
+	}
+
+	return result, nil
+}
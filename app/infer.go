@@ -0,0 +1,203 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/path"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// licenseFiles contains names of files with license text
+var licenseFiles = []string{"LICENSE", "LICENSE.md", "LICENSE.txt", "COPYING"}
+
+// licenseSignatures contains SPDX identifiers of licenses and text fragments
+// used for their detection. More specific licenses must go first.
+var licenseSignatures = []struct {
+	ID        string
+	Fragments []string
+}{
+	{"Apache-2.0", []string{"apache license", "version 2.0"}},
+	{"LGPL-3.0", []string{"gnu lesser general public license", "version 3"}},
+	{"AGPL-3.0", []string{"gnu affero general public license", "version 3"}},
+	{"GPL-3.0", []string{"gnu general public license", "version 3"}},
+	{"GPL-2.0", []string{"gnu general public license", "version 2"}},
+	{"MPL-2.0", []string{"mozilla public license", "2.0"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+	{"MIT", []string{"permission is hereby granted, free of charge"}},
+	{"ISC", []string{"permission to use, copy, modify, and/or distribute this software"}},
+	{"Unlicense", []string{"this is free and unencumbered software"}},
+}
+
+// scpURLRegex is regex for scp-like git URLs (git@github.com:org/repo.git)
+var scpURLRegex = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// inferVariables reads metadata from existing target directory and uses it as
+// default values for variables
+func inferVariables(targetDir string, vars Variables, info *VariableInfoStore) {
+	if !fsutil.IsDir(targetDir) {
+		return
+	}
+
+	inferred := make(Variables)
+
+	inferred[VAR_REPO_URL] = inferRepoURL(targetDir)
+	inferred[VAR_MODULE_PATH] = inferModulePath(targetDir)
+	inferred[VAR_LICENSE] = inferLicense(targetDir)
+
+	if inferred[VAR_MODULE_PATH] == "" && inferred[VAR_REPO_URL] != "" {
+		inferred[VAR_MODULE_PATH] = strings.TrimPrefix(
+			strings.TrimPrefix(inferred[VAR_REPO_URL], "https://"), "http://",
+		)
+	}
+
+	for v, value := range inferred {
+		if value == "" || !vars.Has(v) {
+			continue
+		}
+
+		varInfo := info.Info[v]
+		value = varInfo.Canonical(value)
+
+		if varInfo.IsDynamic || !varInfo.IsValid(value) {
+			continue
+		}
+
+		varInfo.Inferred = value
+		info.Info[v] = varInfo
+	}
+}
+
+// inferModulePath returns module path from go.mod
+func inferModulePath(dir string) string {
+	fd, err := os.Open(path.Join(dir, "go.mod"))
+
+	if err != nil {
+		return ""
+	}
+
+	defer fd.Close()
+
+	s := bufio.NewScanner(fd)
+
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+
+		if !strings.HasPrefix(line, "module ") && !strings.HasPrefix(line, "module\t") {
+			continue
+		}
+
+		module, _, _ := strings.Cut(strings.TrimSpace(line[6:]), "//")
+
+		return strings.Trim(strings.TrimSpace(module), `"`+"`")
+	}
+
+	return ""
+}
+
+// inferRepoURL returns web URL of repository from git remote
+func inferRepoURL(dir string) string {
+	config, err := readGitConfig(path.Join(dir, ".git/config"))
+
+	if err != nil {
+		return ""
+	}
+
+	url := config["remote.origin.url"]
+
+	if url == "" {
+		var remotes []string
+
+		for key := range config {
+			if strings.HasPrefix(key, "remote.") && strings.HasSuffix(key, ".url") {
+				remotes = append(remotes, key)
+			}
+		}
+
+		if len(remotes) == 0 {
+			return ""
+		}
+
+		slices.Sort(remotes)
+		url = config[remotes[0]]
+	}
+
+	return normalizeRepoURL(url)
+}
+
+// normalizeRepoURL converts git remote URL to web URL
+func normalizeRepoURL(url string) string {
+	url = strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+
+	switch {
+	case strings.HasPrefix(url, "https://"), strings.HasPrefix(url, "http://"):
+		// URL may contain credentials
+		scheme, rest, _ := strings.Cut(url, "://")
+		host, _, _ := strings.Cut(rest, "/")
+
+		if strings.Contains(host, "@") {
+			rest = rest[strings.Index(rest, "@")+1:]
+		}
+
+		return scheme + "://" + rest
+
+	case strings.HasPrefix(url, "ssh://"), strings.HasPrefix(url, "git://"):
+		_, rest, _ := strings.Cut(url, "://")
+
+		if strings.Contains(rest, "@") {
+			rest = rest[strings.Index(rest, "@")+1:]
+		}
+
+		host, repo, _ := strings.Cut(rest, "/")
+		host, _, _ = strings.Cut(host, ":")
+
+		return "https://" + host + "/" + repo
+	}
+
+	if scpURLRegex.MatchString(url) && !strings.HasPrefix(url, "/") {
+		return scpURLRegex.ReplaceAllString(url, "https://$1/$2")
+	}
+
+	return ""
+}
+
+// inferLicense returns SPDX identifier of license used in directory
+func inferLicense(dir string) string {
+	for _, file := range licenseFiles {
+		data, err := os.ReadFile(path.Join(dir, file))
+
+		if err != nil {
+			continue
+		}
+
+		text := strings.Join(strings.Fields(strings.ToLower(string(data))), " ")
+
+	SIGNATURES:
+		for _, license := range licenseSignatures {
+			for _, fragment := range license.Fragments {
+				if !strings.Contains(text, fragment) {
+					continue SIGNATURES
+				}
+			}
+
+			return license.ID
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,492 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/jsonutil"
+	"github.com/essentialkaos/ek/v13/path"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// manifestFiles contains names of supported manifest files
+var manifestFiles = []string{"scratch.yml", "scratch.yaml", "scratch.json"}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Manifest contains template configuration
+type Manifest struct {
+	File string `json:"-" yaml:"-"` // Name of manifest file
+
+	Extends []string            `json:"extends" yaml:"extends"`                     // Names of base templates
+	Engine  string              `json:"engine" yaml:"engine"`                       // Rendering engine (simple or template)
+	Engines map[string]string   `json:"engines,omitempty" yaml:"engines,omitempty"` // Rendering engines of files from base templates (only in snapshots)
+	Vars    []*ManifestVariable `json:"vars" yaml:"vars"`                           // Template variables
+	Raw     []string            `json:"raw" yaml:"raw"`                             // Globs of files copied without changes
+	Rules   []*ManifestRule     `json:"rules" yaml:"rules"`                         // Conditional files rules
+	Hooks   ManifestHooks       `json:"hooks" yaml:"hooks"`                         // Commands executed before and after generation
+	Actions []*ManifestAction   `json:"actions" yaml:"actions"`                     // Built-in actions executed after generation
+}
+
+// ManifestVariable contains info about variable declared in manifest
+type ManifestVariable struct {
+	Name      string   `json:"name" yaml:"name"`
+	Desc      string   `json:"desc" yaml:"desc"`
+	Type      string   `json:"type" yaml:"type"`
+	Options   []string `json:"options" yaml:"options"`
+	Min       *int     `json:"min" yaml:"min"`
+	Max       *int     `json:"max" yaml:"max"`
+	Validator string   `json:"validator" yaml:"validator"`
+	Default   string   `json:"default" yaml:"default"`
+	Derive    string   `json:"derive" yaml:"derive"`
+	Required  *bool    `json:"required" yaml:"required"`
+}
+
+// ManifestRule contains condition for generating files matched by globs
+type ManifestRule struct {
+	Files  []string `json:"files" yaml:"files"`
+	When   string   `json:"when" yaml:"when"`
+	Unless string   `json:"unless" yaml:"unless"`
+}
+
+// ManifestHooks contains commands executed in target directory before and after
+// generation
+type ManifestHooks struct {
+	Pre  []*ManifestHook `json:"pre" yaml:"pre"`
+	Post []*ManifestHook `json:"post" yaml:"post"`
+}
+
+// ManifestHook contains info about hook command
+type ManifestHook struct {
+	Name    string `json:"name" yaml:"name"`
+	Run     string `json:"run" yaml:"run"`
+	Timeout string `json:"timeout" yaml:"timeout"`
+}
+
+// ManifestAction contains info about built-in action executed after generation
+type ManifestAction struct {
+	Action  string   `json:"action" yaml:"action"`   // Action type
+	Files   []string `json:"files" yaml:"files"`     // Globs of generated files (chmod, rename, delete)
+	Mode    string   `json:"mode" yaml:"mode"`       // Octal permissions (chmod)
+	To      string   `json:"to" yaml:"to"`           // New path (rename)
+	File    string   `json:"file" yaml:"file"`       // Path to file (append-to-file)
+	Text    string   `json:"text" yaml:"text"`       // Text (append-to-file, print-note)
+	Message string   `json:"message" yaml:"message"` // Initial commit message (git-init)
+	Author  string   `json:"author" yaml:"author"`   // Initial commit author (git-init)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var varNameRegex = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// readManifest reads manifest from template directory. If template has no manifest
+// it returns nil.
+func readManifest(templateDir string) (*Manifest, error) {
+	for _, file := range manifestFiles {
+		manifestFile := path.Join(templateDir, file)
+
+		if !fsutil.IsExist(manifestFile) {
+			continue
+		}
+
+		manifest := &Manifest{}
+		err := readConfigFile(manifestFile, manifest)
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't read manifest %s: %w", file, err)
+		}
+
+		manifest.File = file
+
+		return manifest, manifest.Validate()
+	}
+
+	return nil, nil
+}
+
+// readConfigFile decodes JSON or YAML file depending on its extension
+func readConfigFile(file string, v any) error {
+	switch strings.ToLower(path.Ext(file)) {
+	case ".json":
+		return jsonutil.Read(file, v)
+
+	case ".yml", ".yaml":
+		data, err := os.ReadFile(file)
+
+		if err != nil {
+			return err
+		}
+
+		return yaml.Unmarshal(data, v)
+	}
+
+	return fmt.Errorf("Unsupported file format %q", path.Ext(file))
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Validate validates manifest data
+func (m *Manifest) Validate() error {
+	switch m.Engine {
+	case "", ENGINE_SIMPLE, ENGINE_TEMPLATE:
+		// ok
+	default:
+		return fmt.Errorf("Unsupported rendering engine %q", m.Engine)
+	}
+
+	for file, engine := range m.Engines {
+		switch engine {
+		case "", ENGINE_SIMPLE, ENGINE_TEMPLATE:
+			// ok
+		default:
+			return fmt.Errorf("Unsupported rendering engine %q for file %s", engine, file)
+		}
+	}
+
+	for _, base := range m.Extends {
+		if base == "" || base == "." || base == ".." || strings.ContainsAny(base, "/\\") {
+			return fmt.Errorf("Invalid base template name %q", base)
+		}
+	}
+
+	names := make(map[string]bool)
+
+	for i, v := range m.Vars {
+		switch {
+		case v == nil:
+			return fmt.Errorf("Variable #%d is empty", i+1)
+		case !varNameRegex.MatchString(v.Name):
+			return fmt.Errorf("Variable #%d has invalid name %q", i+1, v.Name)
+		case names[v.Name]:
+			return fmt.Errorf("Variable %q declared more than once", v.Name)
+		}
+
+		names[v.Name] = true
+
+		if v.Validator != "" {
+			_, err := regexp.Compile(v.Validator)
+
+			if err != nil {
+				return fmt.Errorf("Variable %q has invalid validator: %w", v.Name, err)
+			}
+		}
+
+		err := v.validateType()
+
+		if err != nil {
+			return fmt.Errorf("Variable %q has invalid type settings: %w", v.Name, err)
+		}
+
+		err = v.validateDefault()
+
+		if err != nil {
+			return fmt.Errorf("Variable %q has invalid default value: %w", v.Name, err)
+		}
+	}
+
+	for _, pattern := range m.Raw {
+		_, err := compileGlob(pattern)
+
+		if err != nil {
+			return fmt.Errorf("Invalid raw files pattern %q: %w", pattern, err)
+		}
+	}
+
+	for i, rule := range m.Rules {
+		err := rule.Validate()
+
+		if err != nil {
+			return fmt.Errorf("Rule #%d is invalid: %w", i+1, err)
+		}
+	}
+
+	for i, action := range m.Actions {
+		err := action.Validate()
+
+		if err != nil {
+			return fmt.Errorf("Action #%d is invalid: %w", i+1, err)
+		}
+	}
+
+	for _, stage := range hookStages {
+		for i, hook := range m.Hooks.Get(stage) {
+			err := hook.Validate()
+
+			if err != nil {
+				return fmt.Errorf("Hook #%d (%s) is invalid: %w", i+1, stage, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateType validates variable type and type-specific settings
+func (v *ManifestVariable) validateType() error {
+	switch {
+	case !isValidVarType(v.Type):
+		return fmt.Errorf("Unsupported type %q", v.Type)
+	case v.Type == VAR_TYPE_CHOICE && len(v.Options) == 0:
+		return fmt.Errorf("Choice variable must have options")
+	case v.Type != VAR_TYPE_CHOICE && len(v.Options) != 0:
+		return fmt.Errorf("Options are supported only by choice variables")
+	case v.Type != VAR_TYPE_INT && (v.Min != nil || v.Max != nil):
+		return fmt.Errorf("Min and max are supported only by int variables")
+	case v.Min != nil && v.Max != nil && *v.Min > *v.Max:
+		return fmt.Errorf("Min value is greater than max value")
+	}
+
+	for _, option := range v.Options {
+		if strings.TrimSpace(option) == "" {
+			return fmt.Errorf("Choice variable has empty option")
+		}
+	}
+
+	return nil
+}
+
+// validateDefault validates default value and expression for derived variable
+func (v *ManifestVariable) validateDefault() error {
+	switch {
+	case v.Derive != "" && v.Default != "":
+		return fmt.Errorf("Derived variable can't have default value")
+	case v.Derive != "" && v.Required != nil:
+		return fmt.Errorf("Derived variable can't be required")
+	}
+
+	for _, expr := range []string{v.Default, v.Derive} {
+		_, err := scanStringForVariables(expr)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	// Default value with references to other variables can be checked only
+	// after rendering
+	if v.Default == "" || varRegex.MatchString(v.Default) {
+		return nil
+	}
+
+	info := VariableInfo{
+		Type: v.Type, Options: v.Options, Min: v.Min, Max: v.Max,
+		Validator: v.Validator,
+	}
+
+	if !info.IsValid(info.Canonical(v.Default)) {
+		return fmt.Errorf("Value %q is not valid for this variable", v.Default)
+	}
+
+	return nil
+}
+
+// Validate validates hook data
+func (h *ManifestHook) Validate() error {
+	if h == nil {
+		return fmt.Errorf("Hook is empty")
+	}
+
+	args, err := splitCommand(h.Run)
+
+	switch {
+	case err != nil:
+		return err
+	case len(args) == 0:
+		return fmt.Errorf("Hook doesn't contain command")
+	}
+
+	_, err = scanStringForVariables(h.Run)
+
+	if err != nil {
+		return err
+	}
+
+	if h.Timeout != "" {
+		timeout, err := time.ParseDuration(h.Timeout)
+
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("Invalid timeout %q", h.Timeout)
+		}
+	}
+
+	return nil
+}
+
+// Validate validates action data
+func (a *ManifestAction) Validate() error {
+	if a == nil {
+		return fmt.Errorf("Action is empty")
+	}
+
+	for _, value := range a.values() {
+		_, err := scanStringForVariables(value)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, pattern := range a.Files {
+		_, err := compileGlob(pattern)
+
+		if err != nil {
+			return fmt.Errorf("Invalid files pattern %q: %w", pattern, err)
+		}
+	}
+
+	switch a.Action {
+	case ACTION_TYPE_GIT_INIT:
+		if a.Author != "" && a.Message == "" {
+			return fmt.Errorf("Author can be set only with commit message")
+		}
+
+		if a.Author != "" && !varRegex.MatchString(a.Author) && !authorRegex.MatchString(a.Author) {
+			return fmt.Errorf("Author %q must be in \"Name <email>\" format", a.Author)
+		}
+
+	case ACTION_TYPE_CHMOD:
+		if len(a.Files) == 0 {
+			return fmt.Errorf("Action doesn't contain files patterns")
+		}
+
+		_, err := parseFileMode(a.Mode)
+
+		if err != nil {
+			return err
+		}
+
+	case ACTION_TYPE_RENAME:
+		switch {
+		case len(a.Files) == 0:
+			return fmt.Errorf("Action doesn't contain files patterns")
+		case a.To == "":
+			return fmt.Errorf("Action doesn't contain new path")
+		case !varRegex.MatchString(a.To) && validateTargetPath(strings.TrimSuffix(a.To, "/")) != nil:
+			return fmt.Errorf("Invalid new path %q", a.To)
+		}
+
+	case ACTION_TYPE_DELETE:
+		if len(a.Files) == 0 {
+			return fmt.Errorf("Action doesn't contain files patterns")
+		}
+
+	case ACTION_TYPE_APPEND:
+		switch {
+		case a.File == "":
+			return fmt.Errorf("Action doesn't contain file path")
+		case a.Text == "":
+			return fmt.Errorf("Action doesn't contain text")
+		case !varRegex.MatchString(a.File) && validateTargetPath(a.File) != nil:
+			return fmt.Errorf("Invalid file path %q", a.File)
+		}
+
+	case ACTION_TYPE_NOTE:
+		if a.Text == "" {
+			return fmt.Errorf("Action doesn't contain text")
+		}
+
+	default:
+		return fmt.Errorf("Unsupported action %q", a.Action)
+	}
+
+	return nil
+}
+
+// Validate validates rule data
+func (r *ManifestRule) Validate() error {
+	switch {
+	case r == nil:
+		return fmt.Errorf("Rule is empty")
+	case len(r.Files) == 0:
+		return fmt.Errorf("Rule doesn't contain files patterns")
+	case strings.TrimSpace(r.When) == "" && strings.TrimSpace(r.Unless) == "":
+		return fmt.Errorf("Rule doesn't contain conditions")
+	}
+
+	for _, pattern := range r.Files {
+		_, err := compileGlob(pattern)
+
+		if err != nil {
+			return fmt.Errorf("Invalid files pattern %q: %w", pattern, err)
+		}
+	}
+
+	for _, cond := range []string{r.When, r.Unless} {
+		_, err := parseCondition(cond)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// VarsInfo returns built-in variables merged with variables from manifest
+func (m *Manifest) VarsInfo() *VariableInfoStore {
+	store := knownVars.Clone()
+
+	if m == nil {
+		return store
+	}
+
+	for _, v := range m.Vars {
+		info, isKnown := store.Info[v.Name]
+
+		if (!isKnown || info.IsDynamic) && v.Derive == "" {
+			store.List = append(store.List, v.Name)
+		}
+
+		if v.Desc != "" {
+			info.Desc = v.Desc
+		} else if info.Desc == "" {
+			info.Desc = v.Name
+		}
+
+		if v.Validator != "" {
+			info.Validator = v.Validator
+		}
+
+		if v.Type != "" {
+			info.Type = v.Type
+			info.Options = v.Options
+			info.Min, info.Max = v.Min, v.Max
+		}
+
+		if v.Default != "" {
+			info.Default = v.Default
+		}
+
+		// Redeclared built-in derived variable becomes regular variable
+		info.Derive = v.Derive
+
+		if v.Derive != "" {
+			info.Default = ""
+		}
+
+		if v.Required != nil {
+			info.IsOptional = !*v.Required
+		}
+
+		info.IsDynamic = v.Derive != ""
+		store.Info[v.Name] = info
+	}
+
+	return store
+}
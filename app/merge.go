@@ -0,0 +1,181 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"slices"
+	"strings"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	MERGE_MARKER_OURS   = "<<<<<<< project"
+	MERGE_MARKER_SEP    = "======="
+	MERGE_MARKER_THEIRS = ">>>>>>> template"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// diffHunk is a change of lines range [Start, End) of the original text
+type diffHunk struct {
+	Start int      // Index of the first replaced line
+	End   int      // Index of the line after the last replaced line
+	Lines []string // New lines
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// mergeLines merges changes made in ours and theirs versions of base text. It
+// returns merged text and number of conflicts. Conflicting changes are wrapped
+// with conflict markers.
+func mergeLines(base, ours, theirs []byte) ([]byte, int) {
+	baseLines := splitLines(base)
+
+	oursHunks := getDiffHunks(diffLines(baseLines, splitLines(ours)))
+	theirsHunks := getDiffHunks(diffLines(baseLines, splitLines(theirs)))
+
+	var result strings.Builder
+	var conflicts, pos int
+
+	for len(oursHunks) > 0 || len(theirsHunks) > 0 {
+		var regionOurs, regionTheirs []diffHunk
+
+		start, end := nextHunkRegion(oursHunks, theirsHunks)
+
+		// Collect all hunks which overlap with region, region can grow while
+		// collecting
+		for {
+			n := len(regionOurs) + len(regionTheirs)
+
+			for len(oursHunks) > 0 && oursHunks[0].Start <= end {
+				end = max(end, oursHunks[0].End)
+				regionOurs, oursHunks = append(regionOurs, oursHunks[0]), oursHunks[1:]
+			}
+
+			for len(theirsHunks) > 0 && theirsHunks[0].Start <= end {
+				end = max(end, theirsHunks[0].End)
+				regionTheirs, theirsHunks = append(regionTheirs, theirsHunks[0]), theirsHunks[1:]
+			}
+
+			if n == len(regionOurs)+len(regionTheirs) {
+				break
+			}
+		}
+
+		writeLines(&result, baseLines[pos:start])
+		pos = end
+
+		oursText := applyHunks(baseLines, start, end, regionOurs)
+		theirsText := applyHunks(baseLines, start, end, regionTheirs)
+
+		switch {
+		case len(regionTheirs) == 0:
+			writeLines(&result, oursText)
+		case len(regionOurs) == 0, slices.Equal(oursText, theirsText):
+			writeLines(&result, theirsText)
+		default:
+			conflicts++
+			writeConflict(&result, oursText, theirsText)
+		}
+	}
+
+	writeLines(&result, baseLines[pos:])
+
+	return []byte(result.String()), conflicts
+}
+
+// getDiffHunks converts edit script to list of hunks
+func getDiffHunks(ops []diffOp) []diffHunk {
+	var result []diffHunk
+	var hunk *diffHunk
+
+	line := 0
+
+	for _, op := range ops {
+		if op.Kind == DIFF_EQUAL {
+			if hunk != nil {
+				result = append(result, *hunk)
+				hunk = nil
+			}
+
+			line++
+			continue
+		}
+
+		if hunk == nil {
+			hunk = &diffHunk{Start: line, End: line}
+		}
+
+		if op.Kind == DIFF_DELETE {
+			hunk.End++
+			line++
+		} else {
+			hunk.Lines = append(hunk.Lines, op.Line)
+		}
+	}
+
+	if hunk != nil {
+		result = append(result, *hunk)
+	}
+
+	return result
+}
+
+// nextHunkRegion returns range of the first hunk from both lists
+func nextHunkRegion(a, b []diffHunk) (int, int) {
+	switch {
+	case len(a) == 0:
+		return b[0].Start, b[0].End
+	case len(b) == 0, a[0].Start <= b[0].Start:
+		return a[0].Start, a[0].End
+	}
+
+	return b[0].Start, b[0].End
+}
+
+// applyHunks applies hunks to base lines in range [start, end)
+func applyHunks(base []string, start, end int, hunks []diffHunk) []string {
+	var result []string
+
+	pos := start
+
+	for _, hunk := range hunks {
+		result = append(result, base[pos:hunk.Start]...)
+		result = append(result, hunk.Lines...)
+		pos = hunk.End
+	}
+
+	return append(result, base[pos:end]...)
+}
+
+// writeLines writes lines to builder
+func writeLines(buf *strings.Builder, lines []string) {
+	for _, line := range lines {
+		buf.WriteString(line)
+	}
+}
+
+// writeConflict writes conflicting changes with conflict markers
+func writeConflict(buf *strings.Builder, ours, theirs []string) {
+	buf.WriteString(MERGE_MARKER_OURS + "\n")
+	writeConflictLines(buf, ours)
+	buf.WriteString(MERGE_MARKER_SEP + "\n")
+	writeConflictLines(buf, theirs)
+	buf.WriteString(MERGE_MARKER_THEIRS + "\n")
+}
+
+// writeConflictLines writes lines of conflicting change and adds line ending if
+// the last line doesn't have it
+func writeConflictLines(buf *strings.Builder, lines []string) {
+	writeLines(buf, lines)
+
+	if len(lines) != 0 && !strings.HasSuffix(lines[len(lines)-1], "\n") {
+		buf.WriteString("\n")
+	}
+}
@@ -0,0 +1,166 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// PARTIALS_DIR is name of directory with partials
+const PARTIALS_DIR = "_partials"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// partialRegex is regex for include directive ({{> name}})
+var partialRegex = regexp.MustCompile(`\{\{>\s*([A-Za-z0-9_.\-/]+)\s*\}\}`)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// PartialPath returns path to partial with given name
+func (t *Template) PartialPath(name string) string {
+	dir := t.Partials[name]
+
+	if dir == "" {
+		return ""
+	}
+
+	return dir + "/" + PARTIALS_DIR + "/" + name
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// isPartialsFile returns true if given template file is a partial
+func isPartialsFile(file string) bool {
+	return file == PARTIALS_DIR || strings.HasPrefix(file, PARTIALS_DIR+"/")
+}
+
+// readPartials returns names of all partials in given directory. Result map
+// contains partial name and path to directory with partials directory.
+func readPartials(dir string) (map[string]string, error) {
+	result := make(map[string]string)
+	partialsDir := dir + "/" + PARTIALS_DIR
+
+	if !fsutil.IsDir(partialsDir) {
+		return result, nil
+	}
+
+	// Directory with partials can be a symlink, but WalkDir doesn't follow it
+	rootDir, err := filepath.EvalSymlinks(partialsDir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = filepath.WalkDir(rootDir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.Type().IsRegular() {
+			result[strings.TrimPrefix(file, rootDir+"/")] = dir
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+// addLayersPartials adds partials from the root of templates layers. Partials
+// from template have higher priority.
+func addLayersPartials(tmpl *Template) error {
+	for _, layer := range templatesLayers {
+		partials, err := readPartials(layer.Path)
+
+		if err != nil {
+			return fmt.Errorf("Can't read partials from %s: %w", layer.Path, err)
+		}
+
+		for name, dir := range partials {
+			if tmpl.Partials[name] == "" {
+				tmpl.Partials[name] = dir
+			}
+		}
+	}
+
+	return nil
+}
+
+// expandPartials replaces all include directives in data with partials content.
+// Stack contains names of partials which are currently expanded.
+func expandPartials(data string, tmpl *Template, stack []string) (string, error) {
+	if !strings.Contains(data, "{{>") {
+		return data, nil
+	}
+
+	var err error
+
+	result := partialRegex.ReplaceAllStringFunc(data, func(directive string) string {
+		if err != nil {
+			return ""
+		}
+
+		var content string
+
+		content, err = readPartial(partialRegex.FindStringSubmatch(directive)[1], tmpl, stack)
+
+		return content
+	})
+
+	return result, err
+}
+
+// readPartial reads partial and expands all include directives in it
+func readPartial(name string, tmpl *Template, stack []string) (string, error) {
+	if slices.Contains(stack, name) {
+		return "", fmt.Errorf(
+			"Partials have cycle: %s → %s", strings.Join(stack, " → "), name,
+		)
+	}
+
+	file := tmpl.PartialPath(name)
+
+	if file == "" {
+		return "", fmt.Errorf("Can't find partial %q", name)
+	}
+
+	data, err := os.ReadFile(file)
+
+	if err != nil {
+		return "", fmt.Errorf("Can't read partial %q: %w", name, err)
+	}
+
+	// Directive is usually placed on a separate line, so we remove line ending
+	// from partial to keep the original line ending
+	content := strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+
+	return expandPartials(content, tmpl, append(stack, name))
+}
+
+// getPartialsNames returns sorted list of partials names
+func getPartialsNames(tmpl *Template) []string {
+	var result []string
+
+	for name := range tmpl.Partials {
+		result = append(result, name)
+	}
+
+	slices.Sort(result)
+
+	return result
+}
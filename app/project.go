@@ -0,0 +1,260 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/jsonutil"
+	"github.com/essentialkaos/ek/v13/sortutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// PROJECT_FILE is name of file with info about generated project
+const PROJECT_FILE = ".scratch.json"
+
+// GIT_TIMEOUT is maximum duration of git commands execution
+const GIT_TIMEOUT = 5 * time.Second
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// privateVariables is list of dynamic variables with info about user and host.
+// Project info file is usually committed to repository, so values of these
+// variables are never saved.
+var privateVariables = []string{
+	VAR_AUTHOR_NAME, VAR_AUTHOR_EMAIL, VAR_USER, VAR_HOSTNAME,
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ProjectInfo contains info about template and variables used for generating
+// project
+type ProjectInfo struct {
+	Template    string    `json:"template"`               // Template name
+	Revision    string    `json:"revision"`               // Hash of template files
+	GitRevision string    `json:"git_revision,omitempty"` // Last commit in templates repository
+	Version     string    `json:"version"`                // Scratch version
+	Date        time.Time `json:"date"`                   // Generation date
+	Vars        Variables `json:"vars"`                   // Variables values
+	Dynamic     Variables `json:"dynamic,omitempty"`      // Values of dynamic variables used for generation
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// readProjectInfo reads info about generated project from file
+func readProjectInfo(file string) (*ProjectInfo, error) {
+	info := &ProjectInfo{}
+	err := jsonutil.Read(file, info)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't read project info from %s: %w", file, err)
+	}
+
+	if info.Template == "" {
+		return nil, fmt.Errorf("Project info file %s doesn't contain template name", file)
+	}
+
+	for name := range info.Vars {
+		if !varNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("Project info file %s contains invalid variable name %q", file, name)
+		}
+	}
+
+	return info, nil
+}
+
+// getProjectInfoObject returns project info file as a template object, so it can
+// be generated with other template files
+func getProjectInfoObject(tmpl *Template) (*TemplateObject, *ProjectInfo, error) {
+	info, err := getProjectInfo(tmpl)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := json.MarshalIndent(info, "", "  ")
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("Can't encode project info: %w", err)
+	}
+
+	obj := &TemplateObject{
+		Name:   PROJECT_FILE,
+		Target: PROJECT_FILE,
+		Mode:   0644,
+		Data:   append(data, '\n'),
+	}
+
+	return obj, info, nil
+}
+
+// getProjectInfo returns info about template and variables
+func getProjectInfo(tmpl *Template) (*ProjectInfo, error) {
+	revision, err := getTemplateRevision(tmpl)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't calculate template revision: %w", err)
+	}
+
+	info := &ProjectInfo{
+		Template:    tmpl.Name,
+		Revision:    revision,
+		GitRevision: getTemplateGitRevision(tmpl),
+		Version:     VER,
+		Date:        time.Now().UTC().Truncate(time.Second),
+		Vars:        make(Variables),
+		Dynamic:     make(Variables),
+	}
+
+	for v, value := range tmpl.Vars {
+		switch {
+		case !tmpl.Info.Info[v].IsDynamic:
+			info.Vars[v] = value
+		case !slices.Contains(privateVariables, v):
+			info.Dynamic[v] = value
+		}
+	}
+
+	return info, nil
+}
+
+// applySavedDynamicVariables replaces values of dynamic variables with values
+// used for project generation, so project can be rendered again without changes
+func applySavedDynamicVariables(vars Variables, info *VariableInfoStore, project *ProjectInfo) {
+	for v, value := range project.Dynamic {
+		if vars.Has(v) && info.Info[v].IsDynamic {
+			vars[v] = value
+		}
+	}
+}
+
+// getTemplateRevision returns hash of all template files including manifest
+func getTemplateRevision(tmpl *Template) (string, error) {
+	files := slices.Clone(tmpl.Data)
+
+	if tmpl.Manifest != nil && tmpl.Manifest.File != "" {
+		files = append(files, tmpl.Manifest.File)
+	}
+
+	sortutil.StringsNatural(files)
+
+	hasher := sha256.New()
+
+	for _, file := range files {
+		err := hashTemplateFile(hasher, tmpl.SourceDir(file), file)
+
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err := hashBaseManifests(hasher, tmpl.Bases)
+
+	if err != nil {
+		return "", err
+	}
+
+	for _, name := range getPartialsNames(tmpl) {
+		err = hashTemplateFile(hasher, tmpl.Partials[name], PARTIALS_DIR+"/"+name)
+
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return "sha256:" + hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// hashBaseManifests writes manifests of base templates to hasher
+func hashBaseManifests(w io.Writer, bases []*Template) error {
+	for _, base := range bases {
+		if base.Manifest != nil && base.Manifest.File != "" {
+			err := hashTemplateFile(w, base.Path, base.Manifest.File)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		err := hashBaseManifests(w, base.Bases)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hashTemplateFile writes name, type and data of template file to hasher
+func hashTemplateFile(w io.Writer, templateDir, file string) error {
+	sourceFile := templateDir + "/" + file
+	info, err := os.Lstat(sourceFile)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "%s\x00%s\x00", file, info.Mode())
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		link, err := os.Readlink(sourceFile)
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "%s\x00", link)
+
+	case info.Mode().IsRegular():
+		fd, err := os.Open(sourceFile)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(w, fd)
+		fd.Close()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getTemplateGitRevision returns hash of the last commit which changed template
+// if template is stored in git repository
+func getTemplateGitRevision(tmpl *Template) string {
+	ctx, cancel := context.WithTimeout(context.Background(), GIT_TIMEOUT)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "log", "-1", "--format=%H", "--", ".")
+	cmd.Dir = tmpl.Path
+
+	output, err := cmd.Output()
+
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(output))
+}
@@ -0,0 +1,174 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	COND_EQUAL     = "=="
+	COND_NOT_EQUAL = "!="
+	COND_TRUE      = "true"
+	COND_FALSE     = "false"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Condition is parsed condition of rule
+type Condition struct {
+	Var   string // Variable name
+	Op    string // Operator
+	Value string // Value for comparison
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// falseValues contains values which are treated as false in conditions
+var falseValues = []string{"false", "no", "n", "off", "0"}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Match returns true if rule applies to given file
+func (r *ManifestRule) Match(file string) bool {
+	for _, pattern := range r.Files {
+		if matchGlob(pattern, file) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Check returns true if file matched by rule must be generated
+func (r *ManifestRule) Check(vars Variables) bool {
+	if r.When != "" {
+		cond, _ := parseCondition(r.When)
+
+		if cond == nil || !cond.Check(vars) {
+			return false
+		}
+	}
+
+	if r.Unless != "" {
+		cond, _ := parseCondition(r.Unless)
+
+		if cond == nil || cond.Check(vars) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Conditions returns all conditions of rule
+func (r *ManifestRule) Conditions() []*Condition {
+	var result []*Condition
+
+	for _, c := range []string{r.When, r.Unless} {
+		cond, _ := parseCondition(c)
+
+		if cond != nil {
+			result = append(result, cond)
+		}
+	}
+
+	return result
+}
+
+// String returns rule conditions as a string
+func (r *ManifestRule) String() string {
+	var result []string
+
+	if r.When != "" {
+		result = append(result, "when "+strings.TrimSpace(r.When))
+	}
+
+	if r.Unless != "" {
+		result = append(result, "unless "+strings.TrimSpace(r.Unless))
+	}
+
+	return strings.Join(result, " and ")
+}
+
+// Check returns true if condition is satisfied
+func (c *Condition) Check(vars Variables) bool {
+	value := vars[c.Var]
+
+	switch c.Op {
+	case COND_EQUAL:
+		return value == c.Value
+	case COND_NOT_EQUAL:
+		return value != c.Value
+	case COND_FALSE:
+		return !isTrueValue(value)
+	}
+
+	return isTrueValue(value)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// parseCondition parses condition in one of supported formats: "VAR == value",
+// "VAR != value", "VAR" or "!VAR"
+func parseCondition(cond string) (*Condition, error) {
+	cond = strings.TrimSpace(cond)
+
+	if cond == "" {
+		return nil, nil
+	}
+
+	result := &Condition{}
+
+	switch {
+	case strings.Contains(cond, COND_EQUAL):
+		result.Op = COND_EQUAL
+	case strings.Contains(cond, COND_NOT_EQUAL):
+		result.Op = COND_NOT_EQUAL
+	case strings.HasPrefix(cond, "!"):
+		result.Op, result.Var = COND_FALSE, strings.TrimSpace(cond[1:])
+	default:
+		result.Op, result.Var = COND_TRUE, cond
+	}
+
+	if result.Var == "" {
+		name, value, _ := strings.Cut(cond, result.Op)
+		result.Var = strings.TrimSpace(name)
+		result.Value = unquoteValue(strings.TrimSpace(value))
+	}
+
+	if !varNameRegex.MatchString(result.Var) {
+		return nil, fmt.Errorf("Invalid variable name %q in condition %q", result.Var, cond)
+	}
+
+	return result, nil
+}
+
+// unquoteValue removes quotes around value
+func unquoteValue(value string) string {
+	if len(value) < 2 {
+		return value
+	}
+
+	if (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+
+	return value
+}
+
+// isTrueValue returns true if given value is treated as true
+func isTrueValue(value string) bool {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	return value != "" && !slices.Contains(falseValues, value)
+}
@@ -0,0 +1,259 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/path"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	JOURNAL_PLACED uint8 = iota // Object placed to target directory
+	JOURNAL_MOVED               // Existing object moved to another place
+	JOURNAL_MKDIR               // Directory created in target directory
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// StagingDir is temporary directory used for rendering template before moving it
+// to target directory
+type StagingDir struct {
+	Path      string // Path to staging directory
+	TargetDir string // Path to target directory
+
+	journal []journalRecord
+}
+
+// journalRecord contains info about change in target directory
+type journalRecord struct {
+	Type uint8
+	Path string
+	From string
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// newStagingDir creates staging directory in given directory on the same file
+// system as target directory, so objects can be moved to the target directory
+// using rename
+func newStagingDir(baseDir, targetDir string) (*StagingDir, error) {
+	dir, err := os.MkdirTemp(baseDir, "."+path.Base(targetDir)+".scratch-")
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't create staging directory: %w", err)
+	}
+
+	for _, subDir := range []string{"data", "trash"} {
+		err = os.Mkdir(dir+"/"+subDir, 0700)
+
+		if err != nil {
+			os.RemoveAll(dir)
+			return nil, fmt.Errorf("Can't create staging directory: %w", err)
+		}
+	}
+
+	return &StagingDir{Path: dir, TargetDir: targetDir}, nil
+}
+
+// DataDir returns path to directory with rendered data
+func (s *StagingDir) DataDir() string {
+	return s.Path + "/data"
+}
+
+// Clean removes staging directory
+func (s *StagingDir) Clean() {
+	os.RemoveAll(s.Path)
+}
+
+// Commit moves all staged objects to target directory. If any error occurs, all
+// changes in target directory are reverted.
+func (s *StagingDir) Commit(objects []*TemplateObject, actions map[*TemplateObject]string, dirModes map[string]os.FileMode, report *GenerationReport) error {
+	if !fsutil.IsExist(s.TargetDir) && !fsutil.IsLink(s.TargetDir) {
+		return s.commitNew(objects, dirModes, report)
+	}
+
+	var created, overwritten, backedUp []string
+
+	for i, obj := range objects {
+		targetFile := s.TargetDir + "/" + obj.Target
+
+		err := s.makeDirs(path.Dir(obj.Target))
+
+		if err != nil {
+			return s.rollback(err)
+		}
+
+		switch actions[obj] {
+		case ACTION_BACKUP:
+			backup, err := backupFile(targetFile)
+
+			if err != nil {
+				return s.rollback(err)
+			}
+
+			s.record(JOURNAL_MOVED, backup, targetFile)
+			backedUp = append(backedUp, obj.Target+" → "+path.Base(backup))
+
+		case ACTION_OVERWRITE:
+			trashFile := s.Path + "/trash/" + strconv.Itoa(i)
+			err = os.Rename(targetFile, trashFile)
+
+			if err != nil {
+				return s.rollback(err)
+			}
+
+			s.record(JOURNAL_MOVED, trashFile, targetFile)
+			overwritten = append(overwritten, obj.Target)
+
+		default:
+			created = append(created, obj.Target)
+		}
+
+		err = os.Rename(s.DataDir()+"/"+obj.Target, targetFile)
+
+		if err != nil {
+			return s.rollback(err)
+		}
+
+		s.record(JOURNAL_PLACED, targetFile, "")
+	}
+
+	// Permissions for directories are applied after all files are moved,
+	// because some directories can be read-only
+	for _, r := range s.journal {
+		if r.Type != JOURNAL_MKDIR {
+			continue
+		}
+
+		mode, ok := dirModes[strings.TrimPrefix(r.Path, s.TargetDir+"/")]
+
+		if ok {
+			err := os.Chmod(r.Path, mode)
+
+			if err != nil {
+				return s.rollback(err)
+			}
+		}
+	}
+
+	report.Created = append(report.Created, created...)
+	report.Overwritten = append(report.Overwritten, overwritten...)
+	report.BackedUp = append(report.BackedUp, backedUp...)
+
+	return nil
+}
+
+// Remove moves given objects from target directory to staging directory, so
+// they can be restored if commit fails. Objects are removed completely by Clean.
+func (s *StagingDir) Remove(objects []*TemplateObject) error {
+	for i, obj := range objects {
+		targetFile := s.TargetDir + "/" + obj.Target
+		trashFile := s.Path + "/trash/removed-" + strconv.Itoa(i)
+
+		err := os.Rename(targetFile, trashFile)
+
+		if err != nil {
+			return s.rollback(err)
+		}
+
+		s.record(JOURNAL_MOVED, trashFile, targetFile)
+	}
+
+	return nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// commitNew moves staged data to the place of target directory
+func (s *StagingDir) commitNew(objects []*TemplateObject, dirModes map[string]os.FileMode, report *GenerationReport) error {
+	err := os.Rename(s.DataDir(), s.TargetDir)
+
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(s.TargetDir, 0755)
+
+	for dir, mode := range dirModes {
+		if err != nil {
+			break
+		}
+
+		err = os.Chmod(s.TargetDir+"/"+dir, mode)
+	}
+
+	if err != nil {
+		os.RemoveAll(s.TargetDir)
+		return err
+	}
+
+	for _, obj := range objects {
+		report.Created = append(report.Created, obj.Target)
+	}
+
+	return nil
+}
+
+// makeDirs creates all missing directories in target directory
+func (s *StagingDir) makeDirs(dir string) error {
+	if dir == "." || dir == "" {
+		return nil
+	}
+
+	err := s.makeDirs(path.Dir(dir))
+
+	if err != nil {
+		return err
+	}
+
+	targetDir := s.TargetDir + "/" + dir
+
+	if fsutil.IsExist(targetDir) {
+		return nil
+	}
+
+	err = os.Mkdir(targetDir, 0755)
+
+	if err != nil {
+		return err
+	}
+
+	s.record(JOURNAL_MKDIR, targetDir, "")
+
+	return nil
+}
+
+// record adds record to journal
+func (s *StagingDir) record(typ uint8, path, from string) {
+	s.journal = append(s.journal, journalRecord{typ, path, from})
+}
+
+// rollback reverts all changes in target directory and returns given error
+func (s *StagingDir) rollback(err error) error {
+	for i := len(s.journal) - 1; i >= 0; i-- {
+		r := s.journal[i]
+
+		switch r.Type {
+		case JOURNAL_PLACED, JOURNAL_MKDIR:
+			os.Remove(r.Path)
+		case JOURNAL_MOVED:
+			os.Rename(r.Path, r.From)
+		}
+	}
+
+	s.journal = nil
+
+	return fmt.Errorf("Can't move generated files to target directory: %w", err)
+}
@@ -9,9 +9,15 @@ package app
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
@@ -20,6 +26,7 @@ import (
 	"github.com/essentialkaos/ek/v13/sortutil"
 	"github.com/essentialkaos/ek/v13/system"
 	"github.com/essentialkaos/ek/v13/timeutil"
+	"github.com/essentialkaos/ek/v13/uuid"
 )
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -34,22 +41,84 @@ const (
 	VAR_CODEBEAT_UUID  = "CODEBEAT_UUID"
 	VAR_CODECLIMATE_ID = "CODECLIMATE_ID"
 
+	VAR_MODULE_PATH = "MODULE_PATH"
+	VAR_REPO_URL    = "REPO_URL"
+	VAR_LICENSE     = "LICENSE"
+
 	VAR_SHORT_NAME_TITLE    = "SHORT_NAME_TITLE"
 	VAR_SHORT_NAME_LOWER    = "SHORT_NAME_LOWER"
 	VAR_SHORT_NAME_UPPER    = "SHORT_NAME_UPPER"
 	VAR_SPEC_CHANGELOG_DATE = "SPEC_CHANGELOG_DATE"
+
+	VAR_YEAR            = "YEAR"
+	VAR_DATE            = "DATE"
+	VAR_AUTHOR_NAME     = "AUTHOR_NAME"
+	VAR_AUTHOR_EMAIL    = "AUTHOR_EMAIL"
+	VAR_GO_VERSION      = "GO_VERSION"
+	VAR_UUID            = "UUID"
+	VAR_TARGET_DIR_NAME = "TARGET_DIR_NAME"
+	VAR_USER            = "USER"
+	VAR_HOSTNAME        = "HOSTNAME"
+)
+
+const (
+	LAYER_OPTION = "option"
+	LAYER_ENV    = "env"
+	LAYER_USER   = "user"
+	LAYER_SYSTEM = "system"
 )
 
+// ENV_TEMPLATES is name of environment variable with list of directories with
+// templates
+const ENV_TEMPLATES = "SCRATCH_TEMPLATES"
+
+// BINARY_CHECK_SIZE is size of data used for binary files detection
+const BINARY_CHECK_SIZE = 8000
+
+// SYSTEM_TEMPLATES_DIR is path to system-wide directory with templates
+const SYSTEM_TEMPLATES_DIR = "/usr/share/scratch/templates"
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 type Variables map[string]string // name → value
 
+type TemplatesLayer struct {
+	Name string // Name of layer (option, env, user or system)
+	Path string // Path to directory with templates
+}
+
 type Template struct {
-	Name string // Name of template
-	Path string // Path to directory with template data
+	Name  string          // Name of template
+	Path  string          // Path to directory with template data
+	Layer *TemplatesLayer // Layer with template
+
+	Shadows []*TemplatesLayer // Lower-priority layers with the same template
+
+	Manifest *Manifest          // Template manifest
+	Info     *VariableInfoStore // Info about variables supported by template
+
+	Bases     []*Template                // Base templates
+	Sources   map[string]*TemplateSource // Sources of files from base templates
+	Overrides map[string]string          // Files which override files from base templates (file → base template)
+	Engines   map[string]string          // Rendering engines of files from base templates (file → engine)
+	Partials  map[string]string          // Partials available in template (name → directory with partials)
 
 	Vars Variables // Variables
 	Data []string  // List of files and directories of template
+
+	Root        string // Template directory used as a component
+	IsComponent bool   // Template is generated as a component of existing project
+}
+
+type TemplateObject struct {
+	Name   string      // Path to object in template
+	Target string      // Path to object in target directory
+	Link   string      // Symlink target
+	Mode   os.FileMode // Object type and permissions
+	IsRaw  bool        // Object must be copied without applying variables
+	Data   []byte      // Generated data of object which is not a part of template
+
+	IsGoTemplate bool // Object must be rendered using Go templates
 }
 
 type VariableInfoStore struct {
@@ -58,9 +127,17 @@ type VariableInfoStore struct {
 }
 
 type VariableInfo struct {
-	Desc      string
-	Validator string
-	IsDynamic bool
+	Desc       string   // Description shown in prompt
+	Validator  string   // Regular expression for value validation
+	Default    string   // Default value (can reference other variables)
+	Derive     string   // Expression for calculating value from other variables
+	Inferred   string   // Value inferred from target directory
+	Type       string   // Value type
+	Options    []string // Options for choice variables
+	Min        *int     // Minimal value of int variables
+	Max        *int     // Maximal value of int variables
+	IsOptional bool     // Variable can have empty value
+	IsDynamic  bool     // Variable value is generated automatically
 }
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -68,19 +145,33 @@ type VariableInfo struct {
 var knownVars = &VariableInfoStore{
 	// Info contains info about all supported variables
 	Info: map[string]VariableInfo{
-		VAR_NAME:        {"Name", `^[a-zA-Z0-9]+[a-zA-Z0-9\_\-\ ]{1,30}$`, false},
-		VAR_SHORT_NAME:  {"Short name (binary name or repository name)", `^[a-z0-9\_\-]{2,32}$`, false},
-		VAR_VERSION:     {"Version (in SemVer/EffVer notation)", `^[0-9]+\.[0-9]*\.?[0-9]*$`, false},
-		VAR_DESC:        {"Description", `^.{16,128}$`, false},
-		VAR_DESC_README: {"Description for README file (part after 'app is… ')", `^.{16,128}$`, false},
-
-		VAR_CODEBEAT_UUID:  {"Codebeat project UUID", ``, false},
-		VAR_CODECLIMATE_ID: {"Code climate project ID", ``, false},
-
-		VAR_SHORT_NAME_TITLE:    {"Short name in title case", ``, true},
-		VAR_SHORT_NAME_LOWER:    {"Short name in lower case", ``, true},
-		VAR_SHORT_NAME_UPPER:    {"Short name in upper case", ``, true},
-		VAR_SPEC_CHANGELOG_DATE: {"Date in spec changelog", ``, true},
+		VAR_NAME:        {Desc: "Name", Validator: `^[a-zA-Z0-9]+[a-zA-Z0-9\_\-\ ]{1,30}$`},
+		VAR_SHORT_NAME:  {Desc: "Short name (binary name or repository name)", Validator: `^[a-z0-9\_\-]{2,32}$`, Default: "{{NAME|kebab}}"},
+		VAR_VERSION:     {Desc: "Version (in SemVer/EffVer notation)", Validator: `^[0-9]+\.[0-9]*\.?[0-9]*$`},
+		VAR_DESC:        {Desc: "Description", Validator: `^.{16,128}$`},
+		VAR_DESC_README: {Desc: "Description for README file (part after 'app is… ')", Validator: `^.{16,128}$`, Default: "{{DESC}}"},
+
+		VAR_CODEBEAT_UUID:  {Desc: "Codebeat project UUID"},
+		VAR_CODECLIMATE_ID: {Desc: "Code climate project ID"},
+
+		VAR_MODULE_PATH: {Desc: "Go module path"},
+		VAR_REPO_URL:    {Desc: "Repository URL"},
+		VAR_LICENSE:     {Desc: "License (SPDX identifier)"},
+
+		VAR_SHORT_NAME_TITLE:    {Desc: "Short name in title case", Derive: "{{SHORT_NAME|title}}", IsDynamic: true},
+		VAR_SHORT_NAME_LOWER:    {Desc: "Short name in lower case", Derive: "{{SHORT_NAME|lower}}", IsDynamic: true},
+		VAR_SHORT_NAME_UPPER:    {Desc: "Short name in upper case", Derive: "{{SHORT_NAME|upper}}", IsDynamic: true},
+		VAR_SPEC_CHANGELOG_DATE: {Desc: "Date in spec changelog", IsDynamic: true},
+
+		VAR_YEAR:            {Desc: "Current year", IsDynamic: true},
+		VAR_DATE:            {Desc: "Current date in ISO format", IsDynamic: true},
+		VAR_AUTHOR_NAME:     {Desc: "Author name from git config", IsDynamic: true},
+		VAR_AUTHOR_EMAIL:    {Desc: "Author email from git config", IsDynamic: true},
+		VAR_GO_VERSION:      {Desc: "Go version", IsDynamic: true},
+		VAR_UUID:            {Desc: "Random UUID", IsDynamic: true},
+		VAR_TARGET_DIR_NAME: {Desc: "Name of target directory", IsDynamic: true},
+		VAR_USER:            {Desc: "Current user name", IsDynamic: true},
+		VAR_HOSTNAME:        {Desc: "Host name", IsDynamic: true},
 	},
 
 	// List contains variables which requires user input in particular order
@@ -92,10 +183,15 @@ var knownVars = &VariableInfoStore{
 		VAR_DESC_README,
 		VAR_CODEBEAT_UUID,
 		VAR_CODECLIMATE_ID,
+		VAR_MODULE_PATH,
+		VAR_REPO_URL,
+		VAR_LICENSE,
 	},
 }
 
-var varRegex = regexp.MustCompile(`\{\{([A-Z0-9_]+)\}\}`)
+// varRegex is regular expression for variables with optional filters
+// (e.g. {{NAME}} or {{NAME|snake|upper}})
+var varRegex = regexp.MustCompile(`\{\{([A-Z0-9_]+)((?:\|[a-z]+)*)\}\}`)
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
@@ -106,11 +202,11 @@ func (v Variables) Has(name string) bool {
 }
 
 // Count returns number of variables which requires user input
-func (v Variables) Count() int {
+func (v Variables) Count(info *VariableInfoStore) int {
 	var result int
 
 	for n := range v {
-		if !knownVars.Info[n].IsDynamic {
+		if !info.Info[n].IsDynamic {
 			result++
 		}
 	}
@@ -118,196 +214,786 @@ func (v Variables) Count() int {
 	return result
 }
 
+// Clone returns copy of store
+func (s *VariableInfoStore) Clone() *VariableInfoStore {
+	result := &VariableInfoStore{
+		Info: make(map[string]VariableInfo, len(s.Info)),
+		List: append([]string{}, s.List...),
+	}
+
+	for n, info := range s.Info {
+		result.Info[n] = info
+	}
+
+	return result
+}
+
+// IsRaw returns true if given file must be copied without applying variables
+func (t *Template) IsRaw(file string) bool {
+	if t.Manifest == nil {
+		return false
+	}
+
+	for _, pattern := range t.Manifest.Raw {
+		if matchGlob(pattern, file) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetRules returns all rules from manifest which apply to given file
+func (t *Template) GetRules(file string) []*ManifestRule {
+	if t.Manifest == nil {
+		return nil
+	}
+
+	var result []*ManifestRule
+
+	for _, rule := range t.Manifest.Rules {
+		if rule.Match(file) {
+			result = append(result, rule)
+		}
+	}
+
+	return result
+}
+
+// HasHooks returns true if template has hooks
+func (t *Template) HasHooks() bool {
+	return t.Manifest != nil && !t.Manifest.Hooks.IsEmpty()
+}
+
+// HasActions returns true if template has built-in actions
+func (t *Template) HasActions() bool {
+	return t.Manifest != nil && len(t.Manifest.Actions) != 0
+}
+
+// IsIncluded returns true if given file must be generated with current variables
+// values
+func (t *Template) IsIncluded(file string) bool {
+	for _, rule := range t.GetRules(file) {
+		if !rule.Check(t.Vars) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsDir returns true if object is an empty directory
+func (o *TemplateObject) IsDir() bool {
+	return o.Mode.IsDir()
+}
+
+// IsLink returns true if object is a symlink
+func (o *TemplateObject) IsLink() bool {
+	return o.Mode&os.ModeSymlink != 0
+}
+
+// IsGoTemplate returns true if given file must be rendered using Go templates
+func (t *Template) IsGoTemplate(file string) bool {
+	return strings.HasSuffix(file, GO_TEMPLATE_SUFFIX) || t.GetEngine(file) == ENGINE_TEMPLATE
+}
+
+// GetEngine returns rendering engine of given file. Files from base templates
+// are rendered with engines of these templates.
+func (t *Template) GetEngine(file string) string {
+	engine, ok := t.Engines[file]
+
+	switch {
+	case ok:
+		return engine
+	case t.Manifest == nil:
+		return ""
+	}
+
+	return t.Manifest.Engine
+}
+
 // IsValid validates value
 func (vi VariableInfo) IsValid(value string) bool {
-	if vi.Validator == "" {
+	switch {
+	case value == "" && vi.IsOptional:
+		return true
+	case !vi.isValidType(value):
+		return false
+	case vi.Validator == "":
 		return true
 	}
 
-	return regexp.MustCompile(vi.Validator).MatchString(value)
+	validator := regexp.MustCompile(vi.Validator)
+
+	if vi.Type != VAR_TYPE_LIST {
+		return validator.MatchString(value)
+	}
+
+	// Validator is applied to every item of list
+	for _, item := range splitList(value) {
+		if !validator.MatchString(item) {
+			return false
+		}
+	}
+
+	return true
 }
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
-// getTemplates returns slice with info about all available templates
-func getTemplates() ([]*Template, error) {
-	templatesDir, err := getTemplatesDir()
+// getTemplates returns slice with info about all available templates and
+// errors for templates which can't be read
+func getTemplates() ([]*Template, []error) {
+	var result []*Template
+	var errs []error
+
+	index := make(map[string]*Template)
+	broken := make(map[string]bool)
+
+	for _, layer := range templatesLayers {
+		templates := fsutil.List(
+			layer.Path, true,
+			fsutil.ListingFilter{Perms: "DRX"},
+		)
+
+		sortutil.StringsNatural(templates)
+
+		for _, templateName := range templates {
+			// Directories with underscore prefix contain shared data (e.g. partials)
+			// and names of commands are reserved
+			if strings.HasPrefix(templateName, "_") || slices.Contains(commands, templateName) {
+				continue
+			}
+
+			if index[templateName] != nil {
+				index[templateName].Shadows = append(index[templateName].Shadows, layer)
+				continue
+			}
+
+			// Broken template still shadows templates from lower layers
+			if broken[templateName] {
+				continue
+			}
+
+			template, err := readTemplate(layer, templateName)
+
+			if err != nil {
+				broken[templateName] = true
+				errs = append(errs, fmt.Errorf("Problem with template %q: %w", templateName, err))
+				continue
+			}
+
+			index[templateName] = template
+			result = append(result, template)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return sortutil.NaturalLess(result[i].Name, result[j].Name)
+	})
+
+	return result, errs
+}
+
+// hasTemplate returns true if template with given name is present
+func hasTemplate(templateName string) bool {
+	return findTemplateLayer(templateName) != nil
+}
+
+// getTemplatesLayers returns directories with templates ordered by priority
+// (from highest to lowest)
+func getTemplatesLayers(customDir string) ([]*TemplatesLayer, error) {
+	var result []*TemplatesLayer
+
+	if customDir != "" {
+		err := fsutil.ValidatePerms("DRX", customDir)
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't use directory with templates: %w", err)
+		}
+
+		result = appendTemplatesLayer(result, LAYER_OPTION, customDir)
+	}
+
+	for _, dir := range filepath.SplitList(os.Getenv(ENV_TEMPLATES)) {
+		if dir == "" {
+			continue
+		}
+
+		err := fsutil.ValidatePerms("DRX", dir)
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't use directory with templates from $%s: %w", ENV_TEMPLATES, err)
+		}
+
+		result = appendTemplatesLayer(result, LAYER_ENV, dir)
+	}
+
+	userDir, err := getUserTemplatesDir()
 
 	if err != nil {
 		return nil, err
 	}
 
-	var result []*Template
+	for _, layer := range []*TemplatesLayer{
+		{LAYER_USER, userDir},
+		{LAYER_SYSTEM, SYSTEM_TEMPLATES_DIR},
+	} {
+		if !fsutil.IsExist(layer.Path) {
+			continue
+		}
 
-	templates := fsutil.List(
-		templatesDir, true,
-		fsutil.ListingFilter{Perms: "DRX"},
-	)
+		err = fsutil.ValidatePerms("DRX", layer.Path)
 
-	if len(templates) == 0 {
-		return result, nil
+		if err != nil {
+			return nil, err
+		}
+
+		result = appendTemplatesLayer(result, layer.Name, layer.Path)
 	}
 
-	sortutil.StringsNatural(templates)
+	return result, nil
+}
+
+// getUserTemplatesDir returns path to user directory with templates
+func getUserTemplatesDir() (string, error) {
+	configDir := os.Getenv("XDG_CONFIG_HOME")
 
-	for _, templateName := range templates {
-		template, err := getTemplate(templateName)
+	if configDir == "" {
+		user, err := system.CurrentUser()
 
 		if err != nil {
-			return nil, fmt.Errorf("Problem with template %q: %w", templateName, err)
+			return "", fmt.Errorf("Can't get current user info: %w", err)
 		}
 
-		result = append(result, template)
+		configDir = path.Join(user.HomeDir, ".config")
 	}
 
-	return result, nil
+	return path.Join(configDir, "scratch"), nil
 }
 
-// hasTemplate returns true if template with given name is present
-func hasTemplate(templateName string) bool {
-	templates, err := getTemplates()
+// appendTemplatesLayer appends layer to the list if there is no layer with the same
+// path
+func appendTemplatesLayer(layers []*TemplatesLayer, name, dir string) []*TemplatesLayer {
+	dir, _ = filepath.Abs(path.Clean(dir))
 
-	if err != nil {
-		return false
+	for _, layer := range layers {
+		if layer.Path == dir {
+			return layers
+		}
 	}
 
-	for _, t := range templates {
-		if t.Name == templateName {
-			return true
+	return append(layers, &TemplatesLayer{Name: name, Path: dir})
+}
+
+// findTemplateLayer returns the highest-priority layer with given template
+func findTemplateLayer(templateName string) *TemplatesLayer {
+	if templateName == "" || strings.ContainsAny(templateName, "/\\") ||
+		templateName == "." || templateName == ".." || strings.HasPrefix(templateName, "_") ||
+		slices.Contains(commands, templateName) {
+		return nil
+	}
+
+	for _, layer := range templatesLayers {
+		if fsutil.CheckPerms("DRX", path.Join(layer.Path, templateName)) {
+			return layer
 		}
 	}
 
-	return false
+	return nil
 }
 
-// getTemplatesDir returns path to directory with templates
-func getTemplatesDir() (string, error) {
-	user, err := system.CurrentUser()
+// findBaseTemplateLayer returns layer with base template. Base template with
+// the same name as template is searched in layers with lower priority, so
+// template can extend template which it shadows.
+func findBaseTemplateLayer(baseName, templateName string, layer *TemplatesLayer) *TemplatesLayer {
+	index := slices.Index(templatesLayers, layer)
 
-	if err != nil {
-		return "", fmt.Errorf("Can't get current user info: %w", err)
+	if baseName != templateName || index == -1 {
+		return findTemplateLayer(baseName)
 	}
 
-	templatesDir := path.Join(user.HomeDir, ".config/scratch")
+	for _, lowerLayer := range templatesLayers[index+1:] {
+		if fsutil.CheckPerms("DRX", path.Join(lowerLayer.Path, baseName)) {
+			return lowerLayer
+		}
+	}
 
-	return templatesDir, fsutil.ValidatePerms("DRX", templatesDir)
+	return nil
 }
 
 // getTemplate returns list of all files and directories in template
 func getTemplate(templateName string) (*Template, error) {
-	templatesDir, err := getTemplatesDir()
+	layer := findTemplateLayer(templateName)
+
+	if layer == nil {
+		return nil, fmt.Errorf("Can't find template with name %q", templateName)
+	}
+
+	tmpl, err := readTemplate(layer, templateName)
 
 	if err != nil {
 		return nil, err
 	}
 
-	templateDir := templatesDir + "/" + templateName
+	for _, lowerLayer := range templatesLayers[slices.Index(templatesLayers, layer)+1:] {
+		if fsutil.CheckPerms("DRX", path.Join(lowerLayer.Path, templateName)) {
+			tmpl.Shadows = append(tmpl.Shadows, lowerLayer)
+		}
+	}
 
-	if !fsutil.IsExist(templateDir) {
-		return nil, fmt.Errorf("Can't find template with name %q", templateName)
+	return tmpl, nil
+}
+
+// readTemplate reads template with given name from given layer
+func readTemplate(layer *TemplatesLayer, templateName string) (*Template, error) {
+	tmpl, err := readTemplateData(layer, templateName, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = addLayersPartials(tmpl)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl.Info = tmpl.Manifest.VarsInfo()
+
+	err = checkDerivedVariables(tmpl.Info)
+
+	if err != nil {
+		return nil, err
 	}
 
-	files := fsutil.ListAllFiles(templateDir, false)
-	vars, err := extractVariables(templateDir, files)
+	tmpl.Vars, err = extractVariables(tmpl)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &Template{
-		Name: templateName,
-		Path: templateDir,
-		Vars: vars,
-		Data: files,
-	}, nil
+	return tmpl, nil
 }
 
-// copyTemplate copies all files from template and applies variables
-func copyTemplateData(tmpl *Template, targetDir string) error {
-	var err error
+// readTemplateObjects returns list of files, symlinks and empty directories in
+// template directory
+func readTemplateObjects(templateDir string) ([]string, error) {
+	var result []string
 
-	applyDynamicVariables(tmpl.Vars)
+	// Template directory can be a symlink, but WalkDir doesn't follow it
+	rootDir, err := filepath.EvalSymlinks(templateDir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = filepath.WalkDir(rootDir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if file == rootDir {
+			return nil
+		}
+
+		if d.IsDir() && !fsutil.IsEmptyDir(file) {
+			return nil
+		}
+
+		result = append(result, strings.TrimPrefix(file, rootDir+"/"))
+
+		return nil
+	})
+
+	return result, err
+}
+
+// getTemplateObjects returns list of objects which will be generated from
+// template
+func getTemplateObjects(tmpl *Template) ([]*TemplateObject, error) {
+	var result []*TemplateObject
 
 	for _, file := range tmpl.Data {
-		sourceFile := tmpl.Path + "/" + file
-		targetFile := targetDir + "/" + formatFileName(file, tmpl.Vars)
-		targetFileDir := path.Dir(targetFile)
+		if !tmpl.IsIncluded(file) {
+			continue
+		}
+
+		sourceFile := tmpl.SourcePath(file)
+		info, err := os.Lstat(sourceFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		obj := &TemplateObject{
+			Name:   file,
+			Target: formatFileName(tmpl.ComponentPath(file), tmpl.Vars),
+			Mode:   info.Mode(),
+			IsRaw:  tmpl.IsRaw(file),
+		}
+
+		if !obj.IsRaw && info.Mode().IsRegular() && tmpl.IsGoTemplate(file) {
+			obj.IsGoTemplate = true
+			obj.Target = strings.TrimSuffix(obj.Target, GO_TEMPLATE_SUFFIX)
+		}
+
+		err = validateTargetPath(obj.Target)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid path %q generated for %s: %w", obj.Target, file, err)
+		}
 
-		if !fsutil.IsExist(targetFileDir) {
-			err = os.MkdirAll(targetFileDir, 0755)
+		if obj.IsLink() {
+			obj.Link, err = readTemplateLink(tmpl, tmpl.SourceDir(file), sourceFile)
 
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
 
-		err = copyTemplateFile(sourceFile, targetFile, tmpl.Vars)
+		result = append(result, obj)
+	}
+
+	return result, nil
+}
+
+// readTemplateLink reads symlink from template. Absolute links to files inside
+// template are converted to relative.
+func readTemplateLink(tmpl *Template, templateDir, sourceFile string) (string, error) {
+	link, err := os.Readlink(sourceFile)
+
+	if err != nil {
+		return "", err
+	}
+
+	if filepath.IsAbs(link) && strings.HasPrefix(link, templateDir+"/") {
+		link, err = filepath.Rel(path.Dir(sourceFile), link)
 
 		if err != nil {
-			return err
+			return "", err
 		}
 	}
 
-	return nil
+	if !filepath.IsAbs(link) {
+		link = formatFileName(link, tmpl.Vars)
+	}
+
+	return link, nil
+}
+
+// copyTemplate copies all files from template and applies variables. All files
+// are rendered into staging directory first and moved to target directory only
+// if all of them were rendered successfully.
+func copyTemplateData(tmpl *Template, targetDir string, handler ConflictHandler) (*GenerationReport, error) {
+	objects, err := getTemplateObjects(tmpl)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(objects) == 0 {
+		return nil, fmt.Errorf("Template %q has no files to generate", tmpl.Name)
+	}
+
+	var project *ProjectInfo
+	var projectObj *TemplateObject
+
+	// Component is a part of existing project, so project info is not changed
+	if !tmpl.IsComponent {
+		projectObj, project, err = getProjectInfoObject(tmpl)
+
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, projectObj)
+	}
+
+	stagingDir := path.Dir(targetDir)
+
+	if tmpl.IsComponent {
+		err = checkComponentObjects(objects, targetDir)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// Component must not touch anything outside of its directory
+		stagingDir = targetDir
+	}
+
+	actions, err := resolveConflicts(tmpl, objects, targetDir, handler)
+
+	if err != nil {
+		return nil, err
+	}
+
+	staging, err := newStagingDir(stagingDir, targetDir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer staging.Clean()
+
+	report := &GenerationReport{}
+	dirModes := make(map[string]os.FileMode)
+
+	var staged []*TemplateObject
+
+	for _, obj := range objects {
+		if actions[obj] == ACTION_SKIP {
+			report.Skipped = append(report.Skipped, obj.Target)
+			continue
+		}
+
+		err = makeTargetDirs(tmpl, tmpl.SourceDir(obj.Name), path.Dir(obj.Name), staging.DataDir(), dirModes)
+
+		if err == nil {
+			// File name can contain variables with slashes
+			err = os.MkdirAll(path.Dir(staging.DataDir()+"/"+obj.Target), 0755)
+		}
+
+		if err == nil {
+			err = copyTemplateObject(tmpl, obj, staging.DataDir()+"/"+obj.Target)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't generate file %s: %w", obj.Target, err)
+		}
+
+		staged = append(staged, obj)
+	}
+
+	err = staging.Commit(staged, actions, dirModes, report)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if project != nil && actions[projectObj] != ACTION_SKIP {
+		// Snapshot is required only for updating project, so we can ignore errors here
+		saveTemplateSnapshot(tmpl, project.Revision)
+	}
+
+	return report, nil
+}
+
+// makeTargetDirs creates all missing directories for given template directory and
+// saves their source permissions
+func makeTargetDirs(tmpl *Template, templateDir, dir, targetDir string, dirModes map[string]os.FileMode) error {
+	if dir == "." || dir == "" || dir == tmpl.Root {
+		return nil
+	}
+
+	err := makeTargetDirs(tmpl, templateDir, path.Dir(dir), targetDir, dirModes)
+
+	if err != nil {
+		return err
+	}
+
+	targetSubDir := formatFileName(tmpl.ComponentPath(dir), tmpl.Vars)
+
+	if fsutil.IsExist(targetDir + "/" + targetSubDir) {
+		return nil
+	}
+
+	info, err := os.Stat(templateDir + "/" + dir)
+
+	if err != nil {
+		return err
+	}
+
+	dirModes[targetSubDir] = info.Mode().Perm()
+
+	// Variable value can contain slashes, so we can create more than one directory
+	return os.MkdirAll(targetDir+"/"+targetSubDir, 0755)
+}
+
+// copyTemplateObject copies file, symlink or empty directory from template
+func copyTemplateObject(tmpl *Template, obj *TemplateObject, targetFile string) error {
+	var err error
+
+	switch {
+	case obj.IsDir():
+		err = os.Mkdir(targetFile, 0755)
+
+	case obj.IsLink():
+		return os.Symlink(obj.Link, targetFile)
+
+	default:
+		err = copyTemplateFile(tmpl, obj, targetFile)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Set permissions explicitly, because OpenFile and Mkdir apply umask
+	return os.Chmod(targetFile, obj.Mode.Perm())
 }
 
 // copyTemplateFile copies file and applies variables
-func copyTemplateFile(sourceFile, targetFile string, vars Variables) error {
-	sfd, err := os.OpenFile(sourceFile, os.O_RDONLY, 0)
+func copyTemplateFile(tmpl *Template, obj *TemplateObject, targetFile string) error {
+	tfd, err := os.OpenFile(targetFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
 
 	if err != nil {
 		return err
 	}
 
-	defer sfd.Close()
+	err = renderTemplateFile(tmpl, obj, tfd)
+
+	if err != nil {
+		tfd.Close()
+		return err
+	}
+
+	return tfd.Close()
+}
+
+// renderTemplateFile writes template file with applied variables to given writer
+func renderTemplateFile(tmpl *Template, obj *TemplateObject, w io.Writer) error {
+	if obj.Data != nil {
+		_, err := w.Write(obj.Data)
+		return err
+	}
 
-	tfd, err := os.OpenFile(targetFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	sfd, err := os.OpenFile(tmpl.SourcePath(obj.Name), os.O_RDONLY, 0)
 
 	if err != nil {
 		return err
 	}
 
-	defer tfd.Close()
+	defer sfd.Close()
+
+	return writeTemplateData(sfd, w, tmpl, obj)
+}
+
+// renderTemplateFileToBuffer returns rendered and original file data
+func renderTemplateFileToBuffer(tmpl *Template, obj *TemplateObject) ([]byte, []byte, error) {
+	if obj.Data != nil {
+		return obj.Data, obj.Data, nil
+	}
+
+	source, err := os.ReadFile(tmpl.SourcePath(obj.Name))
+
+	if err != nil {
+		return nil, nil, err
+	}
 
-	r := bufio.NewReader(sfd)
-	s := bufio.NewScanner(r)
-	w := bufio.NewWriter(tfd)
+	var buf bytes.Buffer
 
-	return writeTemplateData(s, w, vars)
+	err = writeTemplateData(bytes.NewReader(source), &buf, tmpl, obj)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return buf.Bytes(), source, nil
 }
 
-// writeTemplateData writes template data
-func writeTemplateData(s *bufio.Scanner, w *bufio.Writer, vars Variables) error {
-	for s.Scan() {
-		line := s.Text()
+// writeTemplateData writes template data. Binary and raw data is copied as is.
+func writeTemplateData(r io.Reader, w io.Writer, tmpl *Template, obj *TemplateObject) error {
+	br := bufio.NewReaderSize(r, BINARY_CHECK_SIZE)
+	bw := bufio.NewWriter(w)
 
-		if varRegex.MatchString(line) {
-			for _, fn := range varRegex.FindAllStringSubmatch(line, -1) {
-				varDef, varValue := fn[0], vars[fn[1]]
-				line = strings.ReplaceAll(line, varDef, varValue)
-			}
+	if obj.IsRaw || isBinaryData(br) {
+		_, err := io.Copy(bw, br)
+
+		if err != nil {
+			return err
+		}
+
+		return bw.Flush()
+	}
+
+	if obj.IsGoTemplate {
+		var text string
+
+		data, err := io.ReadAll(br)
+
+		if err == nil {
+			text, err = expandPartials(string(data), tmpl, nil)
 		}
 
-		_, err := w.WriteString(line + "\n")
+		if err == nil {
+			err = executeGoTemplate(obj.Name, text, tmpl.Vars, tmpl.Info, bw)
+		}
 
 		if err != nil {
 			return err
 		}
+
+		return bw.Flush()
 	}
 
-	return w.Flush()
+	for {
+		// ReadString keeps line endings and has no limit on line length
+		line, err := br.ReadString('\n')
+
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		if line != "" {
+			line, perr := expandPartials(line, tmpl, nil)
+
+			if perr != nil {
+				return perr
+			}
+
+			_, werr := bw.WriteString(applyVariables(line, tmpl.Vars))
+
+			if werr != nil {
+				return werr
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	return bw.Flush()
+}
+
+// applyVariables replaces all variables in given data with their values
+func applyVariables(data string, vars Variables) string {
+	if !strings.Contains(data, "{{") {
+		return data
+	}
+
+	return varRegex.ReplaceAllStringFunc(data, func(varDef string) string {
+		fns := varRegex.FindStringSubmatch(varDef)
+		return applyFilters(vars[fns[1]], fns[2])
+	})
 }
 
 // extractVariables extracts all unique variables from all files in template
-func extractVariables(dir string, files []string) (Variables, error) {
+func extractVariables(tmpl *Template) (Variables, error) {
 	vars := make(Variables)
 
-	for _, dataFile := range files {
-		dataFilePath := dir + "/" + dataFile
-		fileVars, err := scanFileForVariables(dataFilePath)
+	for _, dataFile := range tmpl.Data {
+		dataFilePath := tmpl.SourcePath(dataFile)
+		nameVars, err := scanPathForVariables(dataFilePath, dataFile)
 
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("Can't scan path %s for variables: %w", dataFile, err)
+		}
+
+		for _, nameVar := range nameVars {
+			vars[nameVar] = ""
+		}
+
+		if tmpl.IsRaw(dataFile) || fsutil.IsLink(dataFilePath) || !fsutil.IsRegular(dataFilePath) {
+			continue
+		}
+
+		fileVars, err := scanFileForVariables(tmpl, dataFilePath, tmpl.IsGoTemplate(dataFile))
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't scan file %s for variables: %w", dataFile, err)
 		}
 
 		if len(fileVars) == 0 {
@@ -319,11 +1005,37 @@ func extractVariables(dir string, files []string) (Variables, error) {
 		}
 	}
 
-	return vars, validateVariables(vars)
+	if tmpl.Manifest != nil {
+		for _, rule := range tmpl.Manifest.Rules {
+			for _, cond := range rule.Conditions() {
+				vars[cond.Var] = ""
+			}
+		}
+
+		for _, action := range tmpl.Manifest.Actions {
+			for _, actionVar := range action.Variables() {
+				vars[actionVar] = ""
+			}
+		}
+
+		for _, stage := range hookStages {
+			for _, hook := range tmpl.Manifest.Hooks.Get(stage) {
+				hookVars, _ := scanStringForVariables(hook.Run)
+
+				for _, hookVar := range hookVars {
+					vars[hookVar] = ""
+				}
+			}
+		}
+	}
+
+	addDerivedDependencies(vars, tmpl.Info)
+
+	return vars, validateVariables(vars, tmpl.Info)
 }
 
-// scanFileForVariables scans given file for variables
-func scanFileForVariables(file string) ([]string, error) {
+// scanFileForVariables scans given file and included partials for variables
+func scanFileForVariables(tmpl *Template, file string, isGoTemplate bool) ([]string, error) {
 	fd, err := os.OpenFile(file, os.O_RDONLY, 0)
 
 	if err != nil {
@@ -334,49 +1046,160 @@ func scanFileForVariables(file string) ([]string, error) {
 
 	var result []string
 
-	r := bufio.NewReader(fd)
-	s := bufio.NewScanner(r)
+	r := bufio.NewReaderSize(fd, BINARY_CHECK_SIZE)
 
-	for s.Scan() {
-		line := s.Text()
+	if isBinaryData(r) {
+		return nil, nil
+	}
 
-		if !varRegex.MatchString(line) {
-			continue
+	if isGoTemplate {
+		data, err := io.ReadAll(r)
+
+		if err != nil {
+			return nil, err
+		}
+
+		text, err := expandPartials(string(data), tmpl, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return scanGoTemplateForVariables(path.Base(file), text)
+	}
+
+	for {
+		line, err := r.ReadString('\n')
+
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		line, perr := expandPartials(line, tmpl, nil)
+
+		if perr != nil {
+			return nil, perr
 		}
 
-		fn := varRegex.FindAllStringSubmatch(line, -1)
+		lineVars, serr := scanStringForVariables(line)
 
-		for _, fns := range fn {
-			result = append(result, fns[1])
+		if serr != nil {
+			return nil, serr
 		}
+
+		result = append(result, lineVars...)
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// scanPathForVariables scans name of template object and target of symlink
+// for variables
+func scanPathForVariables(file, name string) ([]string, error) {
+	result, err := scanStringForVariables(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.Contains(name, "_name_") {
+		result = append(result, "SHORT_NAME")
+	}
+
+	if !fsutil.IsLink(file) {
+		return result, nil
+	}
+
+	link, err := os.Readlink(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	linkVars, err := scanStringForVariables(link)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.Contains(link, "_name_") {
+		linkVars = append(linkVars, "SHORT_NAME")
+	}
+
+	return append(result, linkVars...), nil
+}
+
+// scanStringForVariables returns names of all variables used in given string
+func scanStringForVariables(data string) ([]string, error) {
+	var result []string
+
+	for _, fns := range varRegex.FindAllStringSubmatch(data, -1) {
+		err := validateFilters(fns[2])
+
+		if err != nil {
+			return nil, fmt.Errorf("%w in %s", err, fns[0])
+		}
+
+		result = append(result, fns[1])
 	}
 
 	return result, nil
 }
 
+// isBinaryData returns true if data contains NUL byte in the first
+// BINARY_CHECK_SIZE bytes (the same heuristic is used by git)
+func isBinaryData(r *bufio.Reader) bool {
+	data, _ := r.Peek(BINARY_CHECK_SIZE)
+	return bytes.IndexByte(data, 0) != -1
+}
+
 // applyDynamicVariables generates values for dynamic variables
-func applyDynamicVariables(vars Variables) {
+func applyDynamicVariables(vars Variables, targetDir string) {
+	now := time.Now()
+
 	for v := range vars {
 		switch v {
 		case VAR_SPEC_CHANGELOG_DATE:
-			vars[v] = timeutil.Format(time.Now(), "%a %b %d %Y")
+			vars[v] = timeutil.Format(now, "%a %b %d %Y")
+
+		case VAR_YEAR:
+			vars[v] = timeutil.Format(now, "%Y")
 
-		case VAR_SHORT_NAME_TITLE:
-			vars[v] = strings.Title(vars[VAR_SHORT_NAME])
+		case VAR_DATE:
+			vars[v] = timeutil.Format(now, "%Y-%m-%d")
 
-		case VAR_SHORT_NAME_LOWER:
-			vars[v] = strings.ToLower(vars[VAR_SHORT_NAME])
+		case VAR_AUTHOR_NAME:
+			vars[v], _ = getGitIdentity()
 
-		case VAR_SHORT_NAME_UPPER:
-			vars[v] = strings.ToUpper(vars[VAR_SHORT_NAME])
+		case VAR_AUTHOR_EMAIL:
+			_, vars[v] = getGitIdentity()
+
+		case VAR_GO_VERSION:
+			vars[v] = getGoVersion()
+
+		case VAR_UUID:
+			vars[v] = uuid.UUID4().String()
+
+		case VAR_TARGET_DIR_NAME:
+			vars[v] = path.Base(targetDir)
+
+		case VAR_USER:
+			vars[v] = getUserName()
+
+		case VAR_HOSTNAME:
+			vars[v] = getHostname()
 		}
 	}
 }
 
 // validateVariables validates variable
-func validateVariables(vars Variables) error {
+func validateVariables(vars Variables, info *VariableInfoStore) error {
 	for v := range vars {
-		_, ok := knownVars.Info[v]
+		_, ok := info.Info[v]
 
 		if !ok {
 			return fmt.Errorf("Template contains unknown variable %q", v)
@@ -388,10 +1211,29 @@ func validateVariables(vars Variables) error {
 
 // formatFileName formats file name
 func formatFileName(name string, vars Variables) string {
-	switch {
-	case strings.Contains(name, "_name_"):
+	// _name_ is legacy alias for {{SHORT_NAME}}
+	if strings.Contains(name, "_name_") {
 		name = strings.ReplaceAll(name, "_name_", vars["SHORT_NAME"])
 	}
 
-	return name
+	return applyVariables(name, vars)
+}
+
+// validateTargetPath checks that generated path points to file inside target
+// directory
+func validateTargetPath(name string) error {
+	if strings.HasPrefix(name, "/") {
+		return fmt.Errorf("Path is absolute")
+	}
+
+	for _, segment := range strings.Split(name, "/") {
+		switch segment {
+		case "":
+			return fmt.Errorf("Path contains empty segment")
+		case ".", "..":
+			return fmt.Errorf("Path contains %q segment", segment)
+		}
+	}
+
+	return nil
 }
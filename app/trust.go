@@ -0,0 +1,121 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/jsonutil"
+	"github.com/essentialkaos/ek/v13/path"
+	"github.com/essentialkaos/ek/v13/system"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// TRUST_FILE is name of file with info about trusted templates
+const TRUST_FILE = "trusted.json"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// TrustStore contains hashes of hooks of trusted templates (path → hash)
+type TrustStore map[string]string
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// IsTrusted returns true if hooks of given template are trusted
+func (s TrustStore) IsTrusted(tmpl *Template) bool {
+	return s[tmpl.Path] != "" && s[tmpl.Path] == getHooksHash(tmpl)
+}
+
+// Trust marks hooks of given template as trusted
+func (s TrustStore) Trust(tmpl *Template) {
+	s[tmpl.Path] = getHooksHash(tmpl)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getTrustStoreFile returns path to file with info about trusted templates
+func getTrustStoreFile() (string, error) {
+	dataDir := os.Getenv("XDG_DATA_HOME")
+
+	if dataDir == "" {
+		user, err := system.CurrentUser()
+
+		if err != nil {
+			return "", fmt.Errorf("Can't get current user info: %w", err)
+		}
+
+		dataDir = path.Join(user.HomeDir, ".local/share")
+	}
+
+	return path.Join(dataDir, "scratch", TRUST_FILE), nil
+}
+
+// readTrustStore reads info about trusted templates
+func readTrustStore() (TrustStore, error) {
+	file, err := getTrustStoreFile()
+
+	if err != nil {
+		return nil, err
+	}
+
+	store := make(TrustStore)
+
+	if !fsutil.IsExist(file) {
+		return store, nil
+	}
+
+	err = jsonutil.Read(file, &store)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't read trusted templates from %s: %w", file, err)
+	}
+
+	return store, nil
+}
+
+// writeTrustStore saves info about trusted templates
+func writeTrustStore(store TrustStore) error {
+	file, err := getTrustStoreFile()
+
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(path.Dir(file), 0700)
+
+	if err != nil {
+		return err
+	}
+
+	err = jsonutil.Write(file, store, 0600)
+
+	if err != nil {
+		return fmt.Errorf("Can't save trusted templates to %s: %w", file, err)
+	}
+
+	return nil
+}
+
+// getHooksHash returns hash of template hooks. Any change of hooks requires
+// new confirmation.
+func getHooksHash(tmpl *Template) string {
+	if tmpl.Manifest == nil {
+		return ""
+	}
+
+	data, _ := json.Marshal(tmpl.Manifest.Hooks)
+	hash := sha256.Sum256(data)
+
+	return "sha256:" + hex.EncodeToString(hash[:])
+}
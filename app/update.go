@@ -0,0 +1,363 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/path"
+	"github.com/essentialkaos/ek/v13/pluralize"
+	"github.com/essentialkaos/ek/v13/sortutil"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	UPDATE_CREATE   = "create"
+	UPDATE_UPDATE   = "update"
+	UPDATE_MERGE    = "merge"
+	UPDATE_CONFLICT = "conflict"
+	UPDATE_REJECT   = "reject"
+	UPDATE_DELETE   = "delete"
+	UPDATE_KEEP     = "keep"
+)
+
+// REJECT_SUFFIX is suffix of files with changes which can't be merged
+const REJECT_SUFFIX = ".rej"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// RenderedObject contains template object with rendered data
+type RenderedObject struct {
+	Object *TemplateObject
+	Data   []byte // Rendered data (only for regular files)
+}
+
+// UpdateAction is action for a single file of project
+type UpdateAction struct {
+	Target string          // Path to file relative to project directory
+	Action string          // Action type
+	Object *TemplateObject // Object from new template revision
+	Data   []byte          // Data for writing
+	Reason string          // Reason of action
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// renderTemplateObjects renders all template objects in memory and applies
+// changes made by actions
+func renderTemplateObjects(tmpl *Template) (map[string]*RenderedObject, error) {
+	objects, err := getTemplateObjects(tmpl)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*RenderedObject, len(objects))
+
+	for _, obj := range objects {
+		rendered := &RenderedObject{Object: obj}
+
+		if !obj.IsDir() && !obj.IsLink() {
+			rendered.Data, _, err = renderTemplateFileToBuffer(tmpl, obj)
+
+			if err != nil {
+				return nil, fmt.Errorf("Can't render file %s: %w", obj.Name, err)
+			}
+		}
+
+		result[obj.Target] = rendered
+	}
+
+	return result, applyActionsToObjects(tmpl, result)
+}
+
+// getUpdateActions compares old and new renders of template with project files
+// and returns list of actions required for updating project
+func getUpdateActions(oldObjects, newObjects map[string]*RenderedObject, dir string) []*UpdateAction {
+	var targets []string
+
+	for target := range oldObjects {
+		if newObjects[target] == nil {
+			targets = append(targets, target)
+		}
+	}
+
+	for target := range newObjects {
+		targets = append(targets, target)
+	}
+
+	sortutil.StringsNatural(targets)
+
+	var result []*UpdateAction
+
+	for _, target := range targets {
+		action := getUpdateAction(oldObjects[target], newObjects[target], dir+"/"+target)
+
+		if action != nil && action.Action == UPDATE_DELETE && hasObjectsInDir(newObjects, target) {
+			// Directory is required for files from new template revision
+			continue
+		}
+
+		if action != nil {
+			action.Target = target
+			result = append(result, action)
+		}
+	}
+
+	return result
+}
+
+// getUpdateAction returns action for single file or nil if file must not be
+// changed
+func getUpdateAction(oldObj, newObj *RenderedObject, file string) *UpdateAction {
+	info, err := os.Lstat(file)
+	exists := err == nil
+
+	switch {
+	case newObj == nil:
+		if exists && isSameObject(oldObj, file, info) {
+			// Directory with files added in project must be kept
+			if info.IsDir() && !fsutil.IsEmptyDir(file) {
+				return nil
+			}
+
+			return &UpdateAction{Action: UPDATE_DELETE}
+		}
+
+		if exists && !info.IsDir() {
+			return &UpdateAction{Action: UPDATE_KEEP, Reason: "removed from template, but modified in project"}
+		}
+
+		return nil
+
+	case newObj.Object.IsDir():
+		if !exists {
+			return &UpdateAction{Action: UPDATE_CREATE, Object: newObj.Object}
+		}
+
+		return nil
+
+	case !exists:
+		if oldObj != nil {
+			// File was removed from project, so we must respect that
+			return nil
+		}
+
+		return &UpdateAction{Action: UPDATE_CREATE, Object: newObj.Object, Data: newObj.Data}
+
+	case isSameObject(newObj, file, info):
+		return nil
+
+	case oldObj != nil && isSameRender(oldObj, newObj):
+		// Template wasn't changed, all changes were made in project
+		return nil
+
+	case isSameObject(oldObj, file, info):
+		return &UpdateAction{Action: UPDATE_UPDATE, Object: newObj.Object, Data: newObj.Data}
+
+	case newObj.Object.IsLink() || !info.Mode().IsRegular():
+		return &UpdateAction{Action: UPDATE_KEEP, Reason: "modified in project and template"}
+	}
+
+	current, err := os.ReadFile(file)
+
+	if err != nil {
+		return &UpdateAction{Action: UPDATE_KEEP, Reason: err.Error()}
+	}
+
+	var base []byte
+
+	if oldObj != nil && !oldObj.Object.IsDir() && !oldObj.Object.IsLink() {
+		base = oldObj.Data
+	}
+
+	if isBinaryBytes(base) || isBinaryBytes(current) || isBinaryBytes(newObj.Data) {
+		return &UpdateAction{Action: UPDATE_REJECT, Object: newObj.Object, Data: newObj.Data}
+	}
+
+	merged, conflicts := mergeLines(base, current, newObj.Data)
+
+	if conflicts != 0 {
+		return &UpdateAction{
+			Action: UPDATE_CONFLICT, Object: newObj.Object, Data: merged,
+			Reason: pluralize.P("%d %s", conflicts, "conflict", "conflicts"),
+		}
+	}
+
+	return &UpdateAction{Action: UPDATE_MERGE, Object: newObj.Object, Data: merged}
+}
+
+// applyUpdateActions applies update actions and saves new project info. All
+// changes are staged first and moved to project directory at once, so project
+// is never left partially updated.
+func applyUpdateActions(tmpl *Template, actions []*UpdateAction, dir string) error {
+	projectObj, project, err := getProjectInfoObject(tmpl)
+
+	if err != nil {
+		return err
+	}
+
+	staging, err := newStagingDir(path.Dir(dir), dir)
+
+	if err != nil {
+		return err
+	}
+
+	defer staging.Clean()
+
+	var objects, deleted []*TemplateObject
+
+	conflicts := make(map[*TemplateObject]string)
+
+	for _, action := range actions {
+		obj, err := getUpdatedObject(action, dir)
+
+		if err != nil {
+			return fmt.Errorf("Can't update file %s: %w", action.Target, err)
+		}
+
+		switch {
+		case obj == nil:
+			continue
+		case action.Action == UPDATE_DELETE:
+			deleted = append(deleted, obj)
+			continue
+		}
+
+		objects = append(objects, obj)
+	}
+
+	objects = append(objects, projectObj)
+
+	for _, obj := range objects {
+		err = os.MkdirAll(path.Dir(staging.DataDir()+"/"+obj.Target), 0755)
+
+		if err == nil {
+			err = writeRenderedObject(obj, staging.DataDir()+"/"+obj.Target)
+		}
+
+		if err != nil {
+			return fmt.Errorf("Can't update file %s: %w", obj.Target, err)
+		}
+
+		if fsutil.IsExist(dir+"/"+obj.Target) || fsutil.IsLink(dir+"/"+obj.Target) {
+			conflicts[obj] = ACTION_OVERWRITE
+		}
+	}
+
+	err = staging.Remove(deleted)
+
+	if err != nil {
+		return err
+	}
+
+	err = staging.Commit(objects, conflicts, nil, &GenerationReport{})
+
+	if err != nil {
+		return err
+	}
+
+	// Snapshot is required only for updating project, so we can ignore errors here
+	saveTemplateSnapshot(tmpl, project.Revision)
+
+	return nil
+}
+
+// getUpdatedObject returns object which must be written to project directory
+// for given action
+func getUpdatedObject(action *UpdateAction, dir string) (*TemplateObject, error) {
+	switch action.Action {
+	case UPDATE_CREATE, UPDATE_UPDATE:
+		obj := *action.Object
+		obj.Target, obj.Data = action.Target, action.Data
+		return &obj, nil
+
+	case UPDATE_MERGE, UPDATE_CONFLICT:
+		info, err := os.Stat(dir + "/" + action.Target)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// Keep permissions of file in project
+		return &TemplateObject{
+			Name: action.Target, Target: action.Target,
+			Mode: info.Mode().Perm(), Data: action.Data,
+		}, nil
+
+	case UPDATE_REJECT:
+		return &TemplateObject{
+			Name: action.Target, Target: action.Target + REJECT_SUFFIX,
+			Mode: 0644, Data: action.Data,
+		}, nil
+
+	case UPDATE_DELETE:
+		return &TemplateObject{Name: action.Target, Target: action.Target}, nil
+	}
+
+	return nil, nil
+}
+
+// writeRenderedObject writes file, link or directory with rendered data
+func writeRenderedObject(obj *TemplateObject, file string) error {
+	var err error
+
+	switch {
+	case obj.IsDir():
+		err = os.Mkdir(file, 0755)
+	case obj.IsLink():
+		return os.Symlink(obj.Link, file)
+	default:
+		err = os.WriteFile(file, obj.Data, 0600)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Set permissions explicitly, because WriteFile and Mkdir apply umask
+	return os.Chmod(file, obj.Mode.Perm())
+}
+
+// isSameObject returns true if project file is the same as rendered object
+func isSameObject(obj *RenderedObject, file string, info os.FileInfo) bool {
+	switch {
+	case obj == nil:
+		return false
+
+	case obj.Object.IsDir():
+		return info.IsDir()
+
+	case obj.Object.IsLink():
+		link, err := os.Readlink(file)
+		return err == nil && link == obj.Object.Link
+
+	case !info.Mode().IsRegular():
+		return false
+	}
+
+	data, err := os.ReadFile(file)
+
+	return err == nil && bytes.Equal(data, obj.Data)
+}
+
+// isSameRender returns true if both rendered objects are equal
+func isSameRender(a, b *RenderedObject) bool {
+	return a.Object.Mode.Type() == b.Object.Mode.Type() &&
+		a.Object.Link == b.Object.Link &&
+		bytes.Equal(a.Data, b.Data)
+}
+
+// isBinaryBytes returns true if data contains NUL byte in its beginning
+func isBinaryBytes(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), BINARY_CHECK_SIZE)], 0) != -1
+}
@@ -0,0 +1,127 @@
+package app
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2025 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	VAR_TYPE_STRING = "string"
+	VAR_TYPE_CHOICE = "choice"
+	VAR_TYPE_BOOL   = "bool"
+	VAR_TYPE_INT    = "int"
+	VAR_TYPE_LIST   = "list"
+)
+
+// LIST_SEPARATOR is separator of items in canonical form of list variables
+const LIST_SEPARATOR = ", "
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// varTypes is list of supported variable types
+var varTypes = []string{
+	VAR_TYPE_STRING, VAR_TYPE_CHOICE, VAR_TYPE_BOOL, VAR_TYPE_INT, VAR_TYPE_LIST,
+}
+
+// trueValues contains values which are treated as true for bool variables
+var trueValues = []string{"true", "yes", "y", "on", "1"}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Canonical returns value converted to canonical form of variable type. If value
+// can't be converted, it returns value as is.
+func (vi VariableInfo) Canonical(value string) string {
+	v := strings.TrimSpace(value)
+
+	switch vi.Type {
+	case VAR_TYPE_BOOL:
+		switch {
+		case slices.Contains(trueValues, strings.ToLower(v)):
+			return "true"
+		case slices.Contains(falseValues, strings.ToLower(v)):
+			return "false"
+		}
+
+	case VAR_TYPE_INT:
+		n, err := strconv.Atoi(v)
+
+		if err == nil {
+			return strconv.Itoa(n)
+		}
+
+	case VAR_TYPE_CHOICE:
+		n, err := strconv.Atoi(v)
+
+		if err == nil && n >= 1 && n <= len(vi.Options) {
+			return vi.Options[n-1]
+		}
+
+		for _, option := range vi.Options {
+			if strings.EqualFold(option, v) {
+				return option
+			}
+		}
+
+	case VAR_TYPE_LIST:
+		return strings.Join(splitList(value), LIST_SEPARATOR)
+	}
+
+	return value
+}
+
+// TypedValue returns value converted to variable type
+func (vi VariableInfo) TypedValue(value string) any {
+	switch vi.Type {
+	case VAR_TYPE_BOOL:
+		return value == "true"
+
+	case VAR_TYPE_INT:
+		n, err := strconv.Atoi(value)
+
+		if err == nil {
+			return n
+		}
+
+	case VAR_TYPE_LIST:
+		return splitList(value)
+	}
+
+	return value
+}
+
+// isValidType returns true if value in canonical form is valid for variable type
+func (vi VariableInfo) isValidType(value string) bool {
+	switch vi.Type {
+	case VAR_TYPE_BOOL:
+		return value == "true" || value == "false"
+
+	case VAR_TYPE_INT:
+		n, err := strconv.Atoi(value)
+
+		return err == nil &&
+			(vi.Min == nil || n >= *vi.Min) &&
+			(vi.Max == nil || n <= *vi.Max)
+
+	case VAR_TYPE_CHOICE:
+		return slices.Contains(vi.Options, value)
+	}
+
+	return true
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// isValidVarType returns true if given variable type is supported
+func isValidVarType(typ string) bool {
+	return typ == "" || slices.Contains(varTypes, typ)
+}
@@ -2,7 +2,10 @@ module github.com/essentialkaos/scratch
 
 go 1.23.6
 
-require github.com/essentialkaos/ek/v13 v13.26.2
+require (
+	github.com/essentialkaos/ek/v13 v13.26.2
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/essentialkaos/depsy v1.3.1 // indirect